@@ -9,7 +9,9 @@ require (
 	github.com/charmbracelet/lipgloss v1.1.1-0.20250818141516-e15c2eb5db1d
 	github.com/spf13/pflag v1.0.7
 	golang.org/x/mod v0.27.0
+	golang.org/x/tools v0.35.0
 	golang.org/x/vuln v1.1.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -35,7 +37,5 @@ require (
 	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/telemetry v0.0.0-20250710130107-8d8967aff50b // indirect
 	golang.org/x/text v0.24.0 // indirect
-	golang.org/x/tools v0.35.0 // indirect
 	golang.org/x/tools/go/packages/packagestest v0.1.1-deprecated // indirect
-
 )
@@ -0,0 +1,363 @@
+package deps
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// APIDiff lists changes of the exported API between two versions of a module.
+// It compares declarations only, so changes of behaviour or of types
+// referenced from other modules are not detected.
+type APIDiff struct {
+	Breaking []string // removed or incompatibly changed identifiers
+	Added    []string // new identifiers
+}
+
+// Compatible reports whether the newer version keeps the exported API of the older one
+func (d *APIDiff) Compatible() bool {
+	return len(d.Breaking) == 0
+}
+
+// DiffAPI compares exported declarations of Current and the target version of m.
+// Both versions are downloaded into the module cache unless they are there already.
+func DiffAPI(m Module) (*APIDiff, error) {
+	oldDir, err := downloadModule(m.Root, m.Path, "v"+m.Current.String())
+	if err != nil {
+		return nil, err
+	}
+	newDir, err := downloadModule(m.Root, m.UpgradePath(), "v"+m.TargetVersion().String())
+	if err != nil {
+		return nil, err
+	}
+
+	oldAPI, err := moduleAPI(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newAPI, err := moduleAPI(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffAPI(oldAPI, newAPI), nil
+}
+
+// packageAPI maps exported identifiers of a package, e.g. "Client.Do", to their declarations
+type packageAPI struct {
+	name    string
+	decls   map[string]string
+	aliases map[string]string // exported aliases of types declared in the package
+	// aliasRefs matches references to aliases, nil without aliases
+	aliasRefs *regexp.Regexp
+}
+
+// resolveAliases declares aliases of local types like the types they stand for
+func (p *packageAPI) resolveAliases() {
+	if len(p.aliases) > 0 {
+		names := sortedKeys(p.aliases)
+		for i, name := range names {
+			names[i] = regexp.QuoteMeta(name)
+		}
+		p.aliasRefs = regexp.MustCompile(`\b(?:` + strings.Join(names, "|") + `)\b`)
+	}
+	for alias, target := range p.aliases {
+		d, ok := p.decls[target]
+		if !ok {
+			continue
+		}
+		p.decls[alias] = d
+		for id, member := range p.decls {
+			if rest, ok := strings.CutPrefix(id, target+"."); ok {
+				p.decls[alias+"."+rest] = member
+			}
+		}
+	}
+}
+
+// canonical rewrites references to aliases in decl with the aliased types
+func (p packageAPI) canonical(decl string) string {
+	if p.aliasRefs == nil {
+		return decl
+	}
+	return p.aliasRefs.ReplaceAllStringFunc(decl, func(alias string) string { return p.aliases[alias] })
+}
+
+// moduleAPI collects exported declarations of importable packages of the module at dir,
+// keyed by package directory relative to dir
+func moduleAPI(dir string) (map[string]packageAPI, error) {
+	api := make(map[string]packageAPI)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		// internal packages cannot be imported by dependents
+		if outsideModule(dir, path) || (path != dir && d.Name() == "internal") {
+			return filepath.SkipDir
+		}
+
+		pkg, err := packageDecls(path)
+		if err != nil || pkg.name == "" || pkg.name == "main" {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		api[filepath.ToSlash(rel)] = pkg
+
+		return nil
+	})
+
+	return api, err
+}
+
+// packageDecls parses exported declarations of the package in dir for the current platform
+func packageDecls(dir string) (packageAPI, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return packageAPI{}, err
+	}
+
+	pkg := packageAPI{decls: make(map[string]string), aliases: make(map[string]string)}
+	fset := token.NewFileSet()
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			// a broken file of a published version is not worth failing the whole report
+			continue
+		}
+		pkg.name = f.Name.Name
+		collectDecls(f, pkg)
+	}
+	pkg.resolveAliases()
+
+	return pkg, nil
+}
+
+func collectDecls(f *ast.File, pkg packageAPI) {
+	decls := pkg.decls
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+			if decl.Recv == nil {
+				decls[decl.Name.Name] = "func" + typeParams(decl.Type.TypeParams) + signature(decl.Type)
+				continue
+			}
+			recv, ptr := receiverType(decl.Recv.List[0].Type)
+			if !ast.IsExported(recv) {
+				continue
+			}
+			decls[recv+"."+decl.Name.Name] = fmt.Sprintf("method (%s%s)%s", ptr, recv, signature(decl.Type))
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if !spec.Name.IsExported() {
+						continue
+					}
+					collectType(spec, decls)
+					if target, ok := spec.Type.(*ast.Ident); ok && spec.Assign.IsValid() {
+						pkg.aliases[spec.Name.Name] = target.Name
+					}
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if !name.IsExported() {
+							continue
+						}
+						d := decl.Tok.String()
+						if spec.Type != nil {
+							d += " " + exprString(spec.Type)
+						}
+						decls[name.Name] = d
+					}
+				}
+			}
+		}
+	}
+}
+
+func collectType(spec *ast.TypeSpec, decls map[string]string) {
+	name := spec.Name.Name
+	prefix := "type" + typeParams(spec.TypeParams)
+	if spec.Assign.IsValid() {
+		decls[name] = prefix + " = " + exprString(spec.Type)
+		return
+	}
+
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		decls[name] = prefix + " struct"
+		for _, field := range t.Fields.List {
+			typ := exprString(field.Type)
+			if len(field.Names) == 0 {
+				// embedded fields are named after their type
+				embedded, _ := receiverType(field.Type)
+				if ast.IsExported(embedded) {
+					decls[name+"."+embedded] = "embedded " + typ
+				}
+				continue
+			}
+			for _, n := range field.Names {
+				if n.IsExported() {
+					decls[name+"."+n.Name] = "field " + typ
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		decls[name] = prefix + " interface"
+		for _, m := range t.Methods.List {
+			if len(m.Names) == 0 {
+				decls[name+"."+exprString(m.Type)] = "interface embeds " + exprString(m.Type)
+				continue
+			}
+			if ft, ok := m.Type.(*ast.FuncType); ok {
+				for _, n := range m.Names {
+					decls[name+"."+n.Name] = "interface method" + signature(ft)
+				}
+			}
+		}
+	default:
+		decls[name] = prefix + " " + exprString(spec.Type)
+	}
+}
+
+// exprString prints expr, spelling the empty interface as any
+func exprString(expr ast.Expr) string {
+	return strings.ReplaceAll(types.ExprString(expr), "interface{}", "any")
+}
+
+// receiverType returns the type name of a receiver or embedded field and "*" for pointers
+func receiverType(expr ast.Expr) (string, string) {
+	ptr := ""
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr, ptr = star.X, "*"
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name, ptr
+	case *ast.SelectorExpr:
+		return t.Sel.Name, ptr
+	}
+	return "", ptr
+}
+
+// signature prints parameter and result types of ft, leaving out parameter names
+func signature(ft *ast.FuncType) string {
+	s := "(" + fieldTypes(ft.Params) + ")"
+	if ft.Results != nil && len(ft.Results.List) > 0 {
+		s += " (" + fieldTypes(ft.Results) + ")"
+	}
+	return s
+}
+
+func typeParams(fl *ast.FieldList) string {
+	if fl == nil || len(fl.List) == 0 {
+		return ""
+	}
+	return "[" + fieldTypes(fl) + "]"
+}
+
+func fieldTypes(fl *ast.FieldList) string {
+	if fl == nil {
+		return ""
+	}
+	var parts []string
+	for _, field := range fl.List {
+		typ := exprString(field.Type)
+		for range max(len(field.Names), 1) {
+			parts = append(parts, typ)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func diffAPI(oldAPI, newAPI map[string]packageAPI) *APIDiff {
+	d := &APIDiff{}
+	for _, rel := range sortedKeys(oldAPI) {
+		oldPkg := oldAPI[rel]
+		newPkg, ok := newAPI[rel]
+		if !ok {
+			d.Breaking = append(d.Breaking, fmt.Sprintf("package %s removed", packageLabel(rel, oldPkg)))
+			continue
+		}
+
+		label := packageLabel(rel, newPkg)
+		for _, id := range sortedKeys(oldPkg.decls) {
+			was := oldPkg.decls[id]
+			is, ok := newPkg.decls[id]
+			switch {
+			case !ok:
+				d.Breaking = append(d.Breaking, fmt.Sprintf("%s.%s removed", label, id))
+			case is != was && is != newPkg.canonical(was):
+				d.Breaking = append(d.Breaking, fmt.Sprintf("%s.%s changed: %s -> %s", label, id, was, is))
+			}
+		}
+		for _, id := range sortedKeys(newPkg.decls) {
+			if _, ok := oldPkg.decls[id]; ok {
+				continue
+			}
+			// implementations outside the module no longer satisfy a grown interface
+			if owner, _, ok := strings.Cut(id, "."); ok && oldPkg.decls[owner] == newPkg.decls[owner] &&
+				strings.HasSuffix(oldPkg.decls[owner], " interface") {
+				d.Breaking = append(d.Breaking, fmt.Sprintf("%s.%s added to interface", label, id))
+				continue
+			}
+			d.Added = append(d.Added, label+"."+id)
+		}
+	}
+	for _, rel := range sortedKeys(newAPI) {
+		if _, ok := oldAPI[rel]; !ok {
+			d.Added = append(d.Added, "package "+packageLabel(rel, newAPI[rel]))
+		}
+	}
+
+	return d
+}
+
+// packageLabel names a package by its directory, the root package by its name
+func packageLabel(rel string, pkg packageAPI) string {
+	if rel == "." {
+		return pkg.name
+	}
+	return rel
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,111 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeModule writes files, keyed by path relative to the module root, to a new directory
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/p\n\ngo 1.21\n"
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiffAPI(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after map[string]string
+		breaking      []string
+		added         []string
+	}{
+		{
+			name:   "unchanged",
+			before: map[string]string{"p.go": "package p\n\nfunc F(int) error { return nil }\n"},
+			after:  map[string]string{"p.go": "package p\n\nfunc F(n int) error { return nil }\n\nfunc f() {}\n"},
+		},
+		{
+			name:     "removed function",
+			before:   map[string]string{"p.go": "package p\n\nfunc F() {}\n\nfunc G() {}\n"},
+			after:    map[string]string{"p.go": "package p\n\nfunc G() {}\n"},
+			breaking: []string{"p.F removed"},
+		},
+		{
+			name:     "changed signature",
+			before:   map[string]string{"p.go": "package p\n\nfunc F(int) {}\n"},
+			after:    map[string]string{"p.go": "package p\n\nfunc F(string) {}\n"},
+			breaking: []string{"p.F changed: func(int) -> func(string)"},
+		},
+		{
+			name:     "changed field and method",
+			before:   map[string]string{"p.go": "package p\n\ntype T struct{ N int }\n\nfunc (T) M() {}\n"},
+			after:    map[string]string{"p.go": "package p\n\ntype T struct{ N int64 }\n\nfunc (*T) M() {}\n"},
+			breaking: []string{"p.T.M changed: method (T)() -> method (*T)()", "p.T.N changed: field int -> field int64"},
+		},
+		{
+			name:   "type renamed behind an alias",
+			before: map[string]string{"p.go": "package p\n\ntype Client struct{ Addr string }\n\nfunc New() *Client { return nil }\n"},
+			after: map[string]string{"p.go": "package p\n\ntype Impl struct{ Addr string }\n\n" +
+				"type Client = Impl\n\nfunc New() *Impl { return nil }\n"},
+			added: []string{"p.Impl", "p.Impl.Addr"},
+		},
+		{
+			name:     "grown interface",
+			before:   map[string]string{"p.go": "package p\n\ntype I interface{ A() }\n"},
+			after:    map[string]string{"p.go": "package p\n\ntype I interface {\n\tA()\n\tB()\n}\n"},
+			breaking: []string{"p.I.B added to interface"},
+		},
+		{
+			name:   "added function and package",
+			before: map[string]string{"p.go": "package p\n"},
+			after:  map[string]string{"p.go": "package p\n\nfunc F() {}\n", "sub/sub.go": "package sub\n"},
+			added:  []string{"p.F", "package sub"},
+		},
+		{
+			name:     "removed package",
+			before:   map[string]string{"p.go": "package p\n", "sub/sub.go": "package sub\n"},
+			after:    map[string]string{"p.go": "package p\n"},
+			breaking: []string{"package sub removed"},
+		},
+		{
+			name:   "internal and testdata are no API",
+			before: map[string]string{"p.go": "package p\n", "internal/x/x.go": "package x\n\nfunc X() {}\n", "testdata/t.go": "package t\n"},
+			after:  map[string]string{"p.go": "package p\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldAPI, err := moduleAPI(writeModule(t, tt.before))
+			if err != nil {
+				t.Fatal(err)
+			}
+			newAPI, err := moduleAPI(writeModule(t, tt.after))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d := diffAPI(oldAPI, newAPI)
+			if !reflect.DeepEqual(d.Breaking, tt.breaking) {
+				t.Errorf("breaking = %q, want %q", d.Breaking, tt.breaking)
+			}
+			if !reflect.DeepEqual(d.Added, tt.added) {
+				t.Errorf("added = %q, want %q", d.Added, tt.added)
+			}
+			if d.Compatible() != (len(tt.breaking) == 0) {
+				t.Errorf("Compatible() = %t with breaking %q", d.Compatible(), d.Breaking)
+			}
+		})
+	}
+}
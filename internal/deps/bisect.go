@@ -0,0 +1,207 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BisectResult is the outcome of upgrading modules of one main module with UpgradeBisect
+type BisectResult struct {
+	Upgraded []Module
+	Breaking []Breaking
+	Failed   []ModuleError       // modules go get failed for
+	Rewrites map[string][]string // files with rewritten imports of upgraded majors by their new path
+	Checks   int                 // number of check runs
+}
+
+// Breaking is a module whose upgrade fails the check
+type Breaking struct {
+	Module Module
+	Output string // check output with the module upgraded
+}
+
+// ModuleError is a module that could not be upgraded
+type ModuleError struct {
+	Module Module
+	Err    error
+}
+
+// UpgradeBisect upgrades ms, modules of root, together and verifies the result as
+// configured by opts. When the check fails, the set is bisected to find the modules
+// breaking it, which are left out, and every other module stays upgraded.
+func UpgradeBisect(root Root, ms []Module, opts UpgradeOptions) (BisectResult, error) {
+	snap, err := snapshotModFiles(root)
+	if err != nil {
+		return BisectResult{}, err
+	}
+	b := &bisection{root: root, opts: opts, snap: snap, rewrites: make(map[string]importRewrite)}
+	res := BisectResult{}
+
+	// go get runs on top of the modules before, which it may conflict with, so a module
+	// it fails for is left out rather than bisected
+	var cands []Module
+	for _, m := range ms {
+		rw, err := upgrade(m)
+		if err != nil {
+			res.Failed = append(res.Failed, ModuleError{Module: m, Err: err})
+			continue
+		}
+		b.applied = append(b.applied, m)
+		b.rewrites[m.UpgradePath()] = rw
+		cands = append(cands, m)
+	}
+	if len(cands) == 0 {
+		return res, nil
+	}
+
+	good, breaking, err := bisectBreaking(cands, b.try)
+	res.Breaking = breaking
+	if err != nil {
+		return b.result(res), err
+	}
+	if !sameModules(b.applied, good) {
+		if err := b.apply(good); err != nil {
+			return b.result(res), err
+		}
+	}
+
+	return b.result(res), nil
+}
+
+// bisectBreaking splits cands into modules passing the check together and breaking ones.
+// try upgrades a set of cands on top of each other and checks the result.
+func bisectBreaking(cands []Module, try func(set []Module) (string, bool, error)) ([]Module, []Breaking, error) {
+	out, ok, err := try(cands)
+	if err != nil || ok {
+		return cands, nil, err
+	}
+
+	// bisection assumes the project passes the check before upgrading
+	if baseOut, ok, err := try(nil); err != nil {
+		return nil, nil, err
+	} else if !ok {
+		return nil, nil, fmt.Errorf("check fails before upgrading:\n%s", baseOut)
+	}
+
+	// good passes the check, good+cands fails it with out
+	var good []Module
+	var breaking []Breaking
+	for len(cands) > 0 {
+		// the shortest failing prefix of cands ends with a breaking module
+		lo, hi := 0, len(cands)
+		for hi-lo > 1 {
+			mid := (lo + hi) / 2
+			midOut, ok, err := try(append(good[:len(good):len(good)], cands[:mid]...))
+			if err != nil {
+				return good, breaking, err
+			}
+			if ok {
+				lo = mid
+			} else {
+				hi, out = mid, midOut
+			}
+		}
+		breaking = append(breaking, Breaking{Module: cands[hi-1], Output: out})
+		good = append(good, cands[:hi-1]...)
+		cands = cands[hi:]
+		if len(cands) == 0 {
+			break
+		}
+
+		restOut, ok, err := try(append(good[:len(good):len(good)], cands...))
+		if err != nil {
+			return good, breaking, err
+		}
+		if ok {
+			good = append(good, cands...)
+			break
+		}
+		out = restOut
+	}
+
+	return good, breaking, nil
+}
+
+// bisection tracks which modules are applied to the main module
+type bisection struct {
+	root     Root
+	opts     UpgradeOptions
+	snap     modFiles
+	applied  []Module
+	rewrites map[string]importRewrite // by new module path
+	checks   int
+}
+
+// apply restores the main module and upgrades set on top of it
+func (b *bisection) apply(set []Module) error {
+	if err := b.snap.restore(); err != nil {
+		return err
+	}
+	rws := make([]importRewrite, 0, len(b.applied))
+	for _, m := range b.applied {
+		rws = append(rws, b.rewrites[m.UpgradePath()])
+	}
+	if err := restoreRewrites(rws); err != nil {
+		return fmt.Errorf("restore imports: %w", err)
+	}
+
+	b.applied, b.rewrites = nil, make(map[string]importRewrite)
+	for _, m := range set {
+		rw, err := upgrade(m)
+		if err != nil {
+			return err
+		}
+		b.applied = append(b.applied, m)
+		b.rewrites[m.UpgradePath()] = rw
+	}
+	return nil
+}
+
+// check verifies the main module, returning the output when it fails
+func (b *bisection) check() (string, bool, error) {
+	b.checks++
+	err := b.opts.verify(b.root)
+	var verr *VerifyError
+	switch {
+	case err == nil:
+		return "", true, nil
+	case errors.As(err, &verr):
+		return verr.Output, false, nil
+	}
+	return "", false, err
+}
+
+// try applies set unless it is applied already and checks it, set failing to upgrade
+// fails the check
+func (b *bisection) try(set []Module) (string, bool, error) {
+	if !sameModules(b.applied, set) {
+		if err := b.apply(set); err != nil {
+			return err.Error(), false, nil
+		}
+	}
+	return b.check()
+}
+
+func (b *bisection) result(res BisectResult) BisectResult {
+	res.Upgraded = append([]Module(nil), b.applied...)
+	res.Rewrites = make(map[string][]string)
+	for path, rw := range b.rewrites {
+		if files := rw.Files(); len(files) > 0 {
+			res.Rewrites[path] = files
+		}
+	}
+	res.Checks = b.checks
+	return res
+}
+
+func sameModules(a, b []Module) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Path != b[i].Path || a[i].UpgradePath() != b[i].UpgradePath() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package deps
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBisectBreaking(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		breaking []string // modules failing the check whenever they are upgraded
+		base     bool     // the check fails before upgrading
+		good     []string
+		wantErr  string
+	}{
+		{name: "none", n: 5, good: []string{"m1", "m2", "m3", "m4", "m5"}},
+		{name: "first", n: 5, breaking: []string{"m1"}, good: []string{"m2", "m3", "m4", "m5"}},
+		{name: "last", n: 5, breaking: []string{"m5"}, good: []string{"m1", "m2", "m3", "m4"}},
+		{name: "middle", n: 6, breaking: []string{"m3"}, good: []string{"m1", "m2", "m4", "m5", "m6"}},
+		{name: "several", n: 7, breaking: []string{"m2", "m6", "m7"}, good: []string{"m1", "m3", "m4", "m5"}},
+		{name: "single", n: 1, breaking: []string{"m1"}},
+		{name: "all", n: 3, breaking: []string{"m1", "m2", "m3"}},
+		{name: "broken before upgrading", n: 3, breaking: []string{"m2"}, base: true, wantErr: "check fails before upgrading"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cands []Module
+			for i := 1; i <= tt.n; i++ {
+				cands = append(cands, Module{Path: "m" + strconv.Itoa(i)})
+			}
+			breaks := make(map[string]bool)
+			for _, path := range tt.breaking {
+				breaks[path] = true
+			}
+			try := func(set []Module) (string, bool, error) {
+				if tt.base {
+					return "broken", false, nil
+				}
+				for _, m := range set {
+					if breaks[m.Path] {
+						return "broken by " + m.Path, false, nil
+					}
+				}
+				return "", true, nil
+			}
+
+			good, breaking, err := bisectBreaking(cands, try)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotGood, gotBreaking []string
+			for _, m := range good {
+				gotGood = append(gotGood, m.Path)
+			}
+			for _, b := range breaking {
+				gotBreaking = append(gotBreaking, b.Module.Path)
+				if want := "broken by " + b.Module.Path; b.Output != want {
+					t.Errorf("output of %s = %q, want %q", b.Module.Path, b.Output, want)
+				}
+			}
+			if !reflect.DeepEqual(gotGood, tt.good) {
+				t.Errorf("good = %v, want %v", gotGood, tt.good)
+			}
+			if !reflect.DeepEqual(gotBreaking, tt.breaking) {
+				t.Errorf("breaking = %v, want %v", gotBreaking, tt.breaking)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package deps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cache persists scan results under the user cache dir. Entries are keyed by module path
+// and GOPROXY and are valid for the required version they were computed for until the TTL expires.
+type Cache struct {
+	dir     string
+	goproxy string
+	ttl     time.Duration
+}
+
+type cacheEntry struct {
+	Version string // required version the result was computed for
+	Stored  time.Time
+	Module  Module
+	Major   *Module
+}
+
+// OpenCache prepares the scan cache directory
+func OpenCache(ttl time.Duration) (*Cache, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(base, "modup", "scan")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	out, err := goOutput(Root{}, "env", "GOPROXY")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		dir:     dir,
+		goproxy: strings.TrimSpace(string(out)),
+		ttl:     ttl,
+	}, nil
+}
+
+// Get returns the cached scan result of req and its major upgrade, if any
+func (c *Cache) Get(req Requirement) (Module, *Module, bool) {
+	data, err := os.ReadFile(c.file(req.Path))
+	if err != nil {
+		return Module{}, nil, false
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Module{}, nil, false
+	}
+	if e.Version != req.Version || time.Since(e.Stored) > c.ttl {
+		return Module{}, nil, false
+	}
+
+	// the same module may be required by several roots
+	e.Module.Root, e.Module.Indirect, e.Module.IsTool = req.Root, req.Indirect, req.IsTool
+	if e.Major != nil {
+		e.Major.Root, e.Major.Indirect, e.Major.IsTool = req.Root, req.Indirect, req.IsTool
+	}
+
+	return e.Module, e.Major, true
+}
+
+// Put stores the scan result of req
+func (c *Cache) Put(req Requirement, mod Module, major *Module) error {
+	data, err := json.Marshal(cacheEntry{
+		Version: req.Version,
+		Stored:  time.Now(),
+		Module:  mod,
+		Major:   major,
+	})
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so concurrent scans never read a partial entry
+	tmp, err := os.CreateTemp(c.dir, "entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.file(req.Path))
+}
+
+func (c *Cache) file(path string) string {
+	sum := sha256.Sum256([]byte(c.goproxy + "\x00" + path))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,200 @@
+package deps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ErrNoChangelog means a module version does not ship a changelog
+var ErrNoChangelog = errors.New("no changelog")
+
+// changelogNames are base names of changelog files in order of preference
+var changelogNames = []string{
+	"changelog", "changes", "release_notes", "releases", "history", "version_history", "news",
+}
+
+// maxNotesLines bounds release notes of huge changelogs
+const maxNotesLines = 2000
+
+var (
+	// versionToken matches a version within a heading, e.g. "## [v1.2.3] - 2024-01-01"
+	versionToken = regexp.MustCompile(`(?:^|[^\w.])v?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)(?:[^\w.-]|$)`)
+	// plainHeading matches changelog headings without markdown, e.g. "1.2.3 / 2024-01-01"
+	plainHeading = regexp.MustCompile(`^(?:(?i:version|release)\s+)?\[?v?\d+\.\d+`)
+)
+
+// ReleaseNotes are the changelog sections of versions after Current up to the target version
+type ReleaseNotes struct {
+	File string // changelog file within the module
+	Text string
+}
+
+// GetReleaseNotes downloads the target version of m into the module cache, unless it
+// is there already, and extracts changelog sections of versions between Current and
+// the target version.
+func GetReleaseNotes(m Module) (ReleaseNotes, error) {
+	dir, err := downloadModule(m.Root, m.UpgradePath(), "v"+m.TargetVersion().String())
+	if err != nil {
+		return ReleaseNotes{}, err
+	}
+
+	file, err := findChangelog(dir)
+	if err != nil {
+		return ReleaseNotes{}, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return ReleaseNotes{}, err
+	}
+
+	text := changelogSections(string(data), m.Current, m.TargetVersion())
+	if text == "" {
+		return ReleaseNotes{File: file}, fmt.Errorf("%s has no section between v%s and v%s", file, m.Current, m.TargetVersion())
+	}
+
+	return ReleaseNotes{File: file, Text: text}, nil
+}
+
+// downloadModule returns the module cache directory of path@version
+func downloadModule(root Root, path, version string) (string, error) {
+	out, err := goCommand(root, "mod", "download", "-json", path+"@"+version).Output()
+
+	// failures are reported in the Error field as well
+	var dl struct {
+		Dir   string
+		Error string
+	}
+	if jerr := json.Unmarshal(out, &dl); jerr != nil {
+		if err != nil {
+			return "", fmt.Errorf("go mod download %s@%s: %w", path, version, err)
+		}
+		return "", jerr
+	}
+	if dl.Error != "" {
+		return "", errors.New(dl.Error)
+	}
+	if err != nil {
+		return "", fmt.Errorf("go mod download %s@%s: %w", path, version, err)
+	}
+
+	return dl.Dir, nil
+}
+
+// findChangelog returns the name of the preferred changelog file at the top of dir
+func findChangelog(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	best, rank := "", len(changelogNames)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		base := strings.ToLower(e.Name())
+		base = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(base, ".md"), ".txt"), ".rst")
+		for i, name := range changelogNames {
+			if base == name && i < rank {
+				best, rank = e.Name(), i
+			}
+		}
+	}
+	if best == "" {
+		return "", ErrNoChangelog
+	}
+
+	return best, nil
+}
+
+// changelogSections keeps sections of changelog whose heading names a version
+// in (from, to], in the order they appear. A section ends at the next version heading
+// or markdown heading of its level or above.
+func changelogSections(changelog string, from, to *semver.Version) string {
+	lines := strings.Split(strings.ReplaceAll(changelog, "\r\n", "\n"), "\n")
+
+	var out []string
+	keep, level := false, 0
+	for i, line := range lines {
+		next := ""
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+		if v, ok := headingVersion(line, next); ok {
+			keep = v.GreaterThan(from) && !v.GreaterThan(to)
+			level = headingLevel(line, next)
+		} else if l := headingLevel(line, next); l > 0 && l <= level {
+			// e.g. "## Contributors" after the sections of versions
+			keep = false
+		}
+		if keep {
+			out = append(out, line)
+		}
+		if len(out) >= maxNotesLines {
+			out = append(out, "…")
+			break
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// headingLevel returns the level of a markdown heading, 0 when line is none.
+// next is the following line, which underlines setext markdown headings.
+func headingLevel(line, next string) int {
+	text := strings.TrimSpace(line)
+	if text == "" {
+		return 0
+	}
+	if level := len(text) - len(strings.TrimLeft(text, "#")); level > 0 {
+		if level > 6 || (len(text) > level && text[level] != ' ') {
+			return 0
+		}
+		return level
+	}
+
+	underline := strings.TrimSpace(next)
+	switch {
+	case len(underline) < 3:
+		return 0
+	case strings.Trim(underline, "=") == "":
+		return 1
+	case strings.Trim(underline, "-") == "":
+		return 2
+	}
+	return 0
+}
+
+// headingVersion reports whether line is a changelog heading of a version.
+// next is the following line, which underlines setext markdown headings.
+func headingVersion(line, next string) (*semver.Version, bool) {
+	text := strings.TrimSpace(line)
+	underline := strings.TrimSpace(next)
+	isUnderline := len(underline) >= 3 && (strings.Trim(underline, "=") == "" || strings.Trim(underline, "-") == "")
+
+	switch {
+	case strings.HasPrefix(text, "#"):
+		text = strings.TrimLeft(text, "# ")
+	case isUnderline, plainHeading.MatchString(text):
+	default:
+		return nil, false
+	}
+
+	match := versionToken.FindStringSubmatch(text)
+	if match == nil {
+		return nil, false
+	}
+	v, err := semver.NewVersion(match[1])
+	if err != nil {
+		return nil, false
+	}
+
+	return v, true
+}
@@ -0,0 +1,222 @@
+package deps
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"golang.org/x/mod/module"
+	modzip "golang.org/x/mod/zip"
+)
+
+func TestHeadingVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		line, next string
+		want       string // empty when line is no version heading
+	}{
+		{name: "atx", line: "## v1.2.3", want: "1.2.3"},
+		{name: "atx keep a changelog", line: "## [1.2.3] - 2024-01-01", want: "1.2.3"},
+		{name: "atx prerelease", line: "# v2.0.0-rc.1", want: "2.0.0-rc.1"},
+		{name: "atx two parts", line: "### 1.4", want: "1.4.0"},
+		{name: "atx without version", line: "### Fixed"},
+		{name: "atx version in text", line: "### Require go1.21.5 now"},
+		{name: "setext equals", line: "1.2.3 (2024-01-01)", next: "==================", want: "1.2.3"},
+		{name: "setext dashes", line: "v0.9.0", next: "------", want: "0.9.0"},
+		{name: "setext without version", line: "Bug fixes", next: "---------"},
+		{name: "plain", line: "1.2.3 / 2024-01-01", want: "1.2.3"},
+		{name: "plain release", line: "Release v3.1.0", want: "3.1.0"},
+		{name: "plain version bracket", line: "Version [1.0.1]", want: "1.0.1"},
+		{name: "list item", line: "- bump foo to 1.2.3"},
+		{name: "prose", line: "Upgrading from 1.2.3 needs a migration"},
+		{name: "short underline", line: "Notes for 1.2.3", next: "--"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := headingVersion(tt.line, tt.next)
+			switch {
+			case tt.want == "" && ok:
+				t.Errorf("headingVersion(%q, %q) = %s, want no heading", tt.line, tt.next, v)
+			case tt.want != "" && !ok:
+				t.Errorf("headingVersion(%q, %q) found no heading, want %s", tt.line, tt.next, tt.want)
+			case tt.want != "" && v.String() != tt.want:
+				t.Errorf("headingVersion(%q, %q) = %s, want %s", tt.line, tt.next, v, tt.want)
+			}
+		})
+	}
+}
+
+const testChangelog = `# Changelog
+
+Intro text before any version.
+
+## [1.3.0] - 2024-03-01
+### Added
+- three
+### Fixed
+- three fix
+
+## [1.2.0] - 2024-02-01
+- two
+
+## Contributors
+- someone
+
+1.1.0
+=====
+- one
+
+Other notes
+-----------
+- still one
+
+1.0.0 / 2023-12-01
+- zero
+`
+
+func TestHeadingLevel(t *testing.T) {
+	tests := []struct {
+		line, next string
+		want       int
+	}{
+		{line: "# Changelog", want: 1},
+		{line: "### Added", want: 3},
+		{line: "#123 fixed"},
+		{line: "1.1.0", next: "=====", want: 1},
+		{line: "Other notes", next: "-----------", want: 2},
+		{line: "", next: "---"},
+		{line: "- two"},
+	}
+	for _, tt := range tests {
+		if got := headingLevel(tt.line, tt.next); got != tt.want {
+			t.Errorf("headingLevel(%q, %q) = %d, want %d", tt.line, tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestChangelogSections(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     []string // lines expected, in order
+		absent   []string
+	}{
+		{
+			name: "upper bound inclusive",
+			from: "1.1.0", to: "1.3.0",
+			want:   []string{"## [1.3.0]", "### Added", "### Fixed", "- three fix", "## [1.2.0]"},
+			absent: []string{"1.1.0", "- one", "Intro", "Contributors"},
+		},
+		{
+			name: "non-version heading of the same level ends the section",
+			from: "1.1.0", to: "1.2.0",
+			want:   []string{"## [1.2.0]", "- two"},
+			absent: []string{"## Contributors", "- someone"},
+		},
+		{
+			name: "lower bound exclusive",
+			from: "1.2.0", to: "1.2.0",
+			absent: []string{"1.2.0", "1.3.0"},
+		},
+		{
+			name: "non-version setext heading stays in its section",
+			from: "1.0.0", to: "1.1.0",
+			want:   []string{"1.1.0", "- one", "Other notes", "- still one"},
+			absent: []string{"1.0.0", "1.2.0"},
+		},
+		{
+			name: "plain heading",
+			from: "0.9.0", to: "1.0.0",
+			want:   []string{"1.0.0 / 2023-12-01", "- zero"},
+			absent: []string{"1.1.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changelogSections(testChangelog, semver.MustParse(tt.from), semver.MustParse(tt.to))
+			rest := got
+			for _, w := range tt.want {
+				i := strings.Index(rest, w)
+				if i < 0 {
+					t.Fatalf("sections (%s, %s] lack %q in order:\n%s", tt.from, tt.to, w, got)
+				}
+				rest = rest[i+len(w):]
+			}
+			for _, a := range tt.absent {
+				if strings.Contains(got, a) {
+					t.Errorf("sections (%s, %s] contain %q:\n%s", tt.from, tt.to, a, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGetReleaseNotesFromFileProxy(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	const path, version = "example.com/notes", "v1.2.0"
+	src := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module " + path + "\n\ngo 1.21\n",
+		"notes.go":     "package notes\n",
+		"CHANGELOG.md": "# Changelog\n\n## v1.2.0\n- two\n\n## v1.1.0\n- one\n\n## v1.0.0\n- zero\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	proxy := t.TempDir()
+	dir := filepath.Join(proxy, path, "@v")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	zip, err := os.Create(filepath.Join(dir, version+".zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := modzip.CreateFromDir(zip, module.Version{Path: path, Version: version}, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := zip.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{
+		"list":            version + "\n",
+		version + ".mod":  files["go.mod"],
+		version + ".info": `{"Version":"` + version + `","Time":"2024-01-01T00:00:00Z"}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("GOPROXY", fileProxy(t, proxy))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOFLAGS", "-modcacherw")
+	t.Setenv("GOTOOLCHAIN", "local")
+
+	m := Module{
+		Path:    path,
+		Root:    Root{Dir: t.TempDir()},
+		Current: semver.MustParse("1.0.0"),
+		Latest:  semver.MustParse("1.2.0"),
+	}
+	notes, err := GetReleaseNotes(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes.File != "CHANGELOG.md" {
+		t.Errorf("file = %q, want CHANGELOG.md", notes.File)
+	}
+	if want := "## v1.2.0\n- two\n\n## v1.1.0\n- one"; notes.Text != want {
+		t.Errorf("text = %q, want %q", notes.Text, want)
+	}
+}
@@ -0,0 +1,308 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"golang.org/x/mod/module"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the name of the project configuration file
+const ConfigFile = ".modup.yaml"
+
+// Config is the project policy for upgrades. Module patterns use GOPRIVATE syntax:
+// comma separated path globs matching a module path or any of its prefixes.
+type Config struct {
+	// Ignore lists patterns of modules that are never scanned
+	Ignore []string `yaml:"ignore"`
+	// Pin maps patterns to semver constraints upgrades must satisfy, e.g. "~1.4" or "v1.2.3"
+	Pin map[string]string `yaml:"pin"`
+	// Rules limit upgrades of matching modules, the first matching rule applies
+	Rules []Rule `yaml:"rules"`
+	// Prerelease proposes prereleases newer than the latest release of every module
+	Prerelease bool `yaml:"prerelease"`
+	// MinAge is the cooldown before a release is proposed, e.g. "14d" or "36h"
+	MinAge string `yaml:"min_age"`
+
+	pins   map[string]*semver.Constraints
+	minAge time.Duration
+}
+
+// Rule limits upgrades of modules matching a pattern
+type Rule struct {
+	Match string `yaml:"match"`
+	// Max is the largest allowed update category: major, minor or patch
+	Max string `yaml:"max"`
+	// Prerelease overrides Config.Prerelease for matching modules
+	Prerelease *bool `yaml:"prerelease"`
+	// MinAge overrides Config.MinAge for matching modules
+	MinAge string `yaml:"min_age"`
+
+	minAge time.Duration
+}
+
+// categoryRank orders update categories by how much they change
+var categoryRank = map[string]int{
+	"metadata":   0,
+	"prerelease": 0,
+	"patch":      1,
+	"minor":      2,
+	"major":      3,
+}
+
+// LoadConfig reads and validates the configuration file at path
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := c.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &c, nil
+}
+
+func (c *Config) compile() error {
+	c.pins = make(map[string]*semver.Constraints, len(c.Pin))
+	for pattern, constraint := range c.Pin {
+		cons, err := semver.NewConstraint(constraint)
+		if err != nil {
+			return fmt.Errorf("pin %s: %w", pattern, err)
+		}
+		c.pins[pattern] = cons
+	}
+
+	if err := c.SetMinAge(c.MinAge); err != nil {
+		return err
+	}
+
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if r.Match == "" {
+			return fmt.Errorf("rule %d: match is required", i+1)
+		}
+		if r.Max != "" && r.Max != "major" && r.Max != "minor" && r.Max != "patch" {
+			return fmt.Errorf("rule %s: unknown max category %q, expected major, minor or patch", r.Match, r.Max)
+		}
+		if r.MinAge != "" {
+			age, err := ParseAge(r.MinAge)
+			if err != nil {
+				return fmt.Errorf("rule %s: %w", r.Match, err)
+			}
+			r.minAge = age
+		}
+	}
+
+	return nil
+}
+
+// SetMinAge replaces the default cooldown of c, see ParseAge
+func (c *Config) SetMinAge(age string) error {
+	d, err := ParseAge(age)
+	if err != nil {
+		return err
+	}
+	c.MinAge, c.minAge = age, d
+	return nil
+}
+
+// ParseAge parses a time.Duration that may also be given in days, e.g. "7d".
+// An empty age is zero.
+func ParseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(age, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid min age %q", age)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid min age %q", age)
+	}
+	return d, nil
+}
+
+// Ignored reports whether the module at path must not be scanned
+func (c *Config) Ignored(path string) bool {
+	if c == nil {
+		return false
+	}
+	return module.MatchPrefixPatterns(strings.Join(c.Ignore, ","), path)
+}
+
+// Policy collects the rules of c applying to the module at path
+func (c *Config) Policy(path string) Policy {
+	if c == nil {
+		return Policy{}
+	}
+
+	p := Policy{Prerelease: c.Prerelease, MinAge: c.minAge, age: c.MinAge}
+	// the longest pattern is the most specific pin
+	for pattern, cons := range c.pins {
+		if len(pattern) > len(p.Pin) && module.MatchPrefixPatterns(pattern, path) {
+			p.Pin, p.pin = c.Pin[pattern], cons
+		}
+	}
+	for _, r := range c.Rules {
+		if !module.MatchPrefixPatterns(r.Match, path) {
+			continue
+		}
+		p.Max, p.Match = r.Max, r.Match
+		if r.Prerelease != nil {
+			p.Prerelease = *r.Prerelease
+		}
+		if r.MinAge != "" {
+			p.MinAge, p.age = r.minAge, r.MinAge
+		}
+		break
+	}
+
+	return p
+}
+
+// Policy restricts the versions a single module may be upgraded to
+type Policy struct {
+	Pin        string // semver constraint
+	Max        string // largest allowed update category
+	Match      string // pattern of the rule Max comes from
+	Prerelease bool
+	MinAge     time.Duration // releases younger than that are not proposed
+
+	pin *semver.Constraints
+	age string // MinAge as configured
+}
+
+// VersionSource looks up versions a module can be upgraded to
+type VersionSource interface {
+	// Versions lists versions m can be upgraded to, newest first
+	Versions(m Module) ([]*semver.Version, error)
+	// ReleaseTime returns when version v of the module m is upgraded to was published
+	ReleaseTime(m Module, v *semver.Version) (time.Time, error)
+}
+
+// Allows reports whether upgrading from current to v complies with p regardless
+// of its release time, otherwise it returns the reason v is held back
+func (p Policy) Allows(current, v *semver.Version) (string, bool) {
+	if p.pin != nil && !p.pin.Check(v) {
+		return "pinned to " + p.Pin, false
+	}
+	if p.Max != "" && categoryRank[categorize(current, v)] > categoryRank[p.Max] {
+		return fmt.Sprintf("only %s updates allowed for %s", p.Max, p.Match), false
+	}
+	if v.Prerelease() != "" && current.Prerelease() == "" && !p.Prerelease {
+		return "prereleases not allowed", false
+	}
+	return "", true
+}
+
+// Released reports whether a version released at t is past the cooldown of p,
+// otherwise it returns the reason the version is held back. A zero t means the
+// release time is unknown, such a version is held back too.
+func (p Policy) Released(t time.Time) (string, bool) {
+	if p.MinAge <= 0 {
+		return "", true
+	}
+	if t.IsZero() {
+		return "release time unknown, cannot check the " + p.age + " cooldown", false
+	}
+	if time.Since(t) >= p.MinAge {
+		return "", true
+	}
+	return fmt.Sprintf("released %s, younger than %s", t.Format(time.DateOnly), p.age), false
+}
+
+// Apply holds m back to the newest version allowed by p. src is only queried when
+// Latest is not allowed or prereleases are opted in. Without any allowed version
+// the module is not updatable.
+func (p Policy) Apply(m Module, src VersionSource) Module {
+	if !m.Updatable || m.Current == nil || (p.pin == nil && p.Max == "" && !p.Prerelease && p.MinAge <= 0) {
+		return m
+	}
+
+	reason, ok := p.Allows(m.Current, m.Latest)
+	if ok && p.MinAge > 0 {
+		var err error
+		if m.LatestTime.IsZero() {
+			m.LatestTime, err = src.ReleaseTime(m, m.Latest)
+		}
+		reason, ok = p.Released(m.LatestTime)
+		if err != nil {
+			cause, _, _ := strings.Cut(err.Error(), "\n")
+			reason = fmt.Sprintf("%s (%s)", reason, cause)
+		}
+	}
+	if ok && !p.Prerelease {
+		return m
+	}
+
+	list, err := src.Versions(m)
+	if err != nil {
+		if ok {
+			return m
+		}
+		m.Held, m.Updatable = reason, false
+		return m
+	}
+
+	best, bestTime := p.newest(m, list, src)
+	if ok {
+		// a prerelease newer than the latest release
+		if best != nil && best.GreaterThan(m.Latest) {
+			m.Latest, m.LatestTime = best, bestTime
+			m.UpdateCategory = categorize(m.Current, best)
+		}
+		return m
+	}
+
+	m.Held = reason
+	if best == nil {
+		m.Updatable = false
+		return m
+	}
+
+	m = m.WithTarget(best)
+	m.TargetTime = bestTime
+	return m
+}
+
+// newest returns the first version of list allowed by p and its release time,
+// the release time is only looked up with a cooldown
+func (p Policy) newest(m Module, list []*semver.Version, src VersionSource) (*semver.Version, time.Time) {
+	for _, v := range list {
+		if _, ok := p.Allows(m.Current, v); !ok {
+			continue
+		}
+		if p.MinAge <= 0 {
+			return v, time.Time{}
+		}
+
+		t := m.LatestTime
+		if !v.Equal(m.Latest) {
+			var err error
+			if t, err = src.ReleaseTime(m, v); err != nil {
+				continue
+			}
+		}
+		if _, ok := p.Released(t); ok {
+			return v, t
+		}
+	}
+
+	return nil, time.Time{}
+}
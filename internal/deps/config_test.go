@@ -0,0 +1,169 @@
+package deps
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		age     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{age: "", want: 0},
+		{age: "7d", want: 7 * 24 * time.Hour},
+		{age: "36h", want: 36 * time.Hour},
+		{age: "90m", want: 90 * time.Minute},
+		{age: "-5m", wantErr: true},
+		{age: "-1d", wantErr: true},
+		{age: "1.5d", wantErr: true},
+		{age: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAge(tt.age)
+		switch {
+		case tt.wantErr && err == nil:
+			t.Errorf("ParseAge(%q) = %s, want an error", tt.age, got)
+		case !tt.wantErr && err != nil:
+			t.Errorf("ParseAge(%q): %v", tt.age, err)
+		case got != tt.want:
+			t.Errorf("ParseAge(%q) = %s, want %s", tt.age, got, tt.want)
+		}
+	}
+}
+
+// fakeVersions serves versions newest first with release times, a version missing
+// from released has an unknown release time
+type fakeVersions struct {
+	versions []string
+	released map[string]time.Time
+}
+
+func (f fakeVersions) Versions(Module) ([]*semver.Version, error) {
+	var list []*semver.Version
+	for _, v := range f.versions {
+		list = append(list, semver.MustParse(v))
+	}
+	return list, nil
+}
+
+func (f fakeVersions) ReleaseTime(_ Module, v *semver.Version) (time.Time, error) {
+	return f.released[v.String()], nil
+}
+
+func TestPolicyApply(t *testing.T) {
+	old := time.Now().AddDate(0, -1, 0)
+	fresh := time.Now().Add(-time.Hour)
+	yes := true
+	src := fakeVersions{
+		versions: []string{"1.5.0-rc.1", "1.4.0", "1.3.1", "1.3.0", "1.2.5", "1.2.1"},
+		released: map[string]time.Time{"1.5.0-rc.1": fresh, "1.3.1": old, "1.3.0": old, "1.2.5": old, "1.2.1": old},
+	}
+
+	tests := []struct {
+		name       string
+		config     Config
+		src        fakeVersions
+		want       string // target version, empty when not updatable
+		held       string // expected in the reason the module is held back
+		updatable  bool
+		wantLatest string
+	}{
+		{name: "no rules", want: "1.4.0", updatable: true},
+		{
+			name:   "max patch falls back to the newest patch",
+			config: Config{Rules: []Rule{{Match: "example.com", Max: "patch"}}},
+			want:   "1.2.5", held: "only patch updates allowed for example.com", updatable: true,
+		},
+		{
+			name:   "max patch without a patch release",
+			config: Config{Rules: []Rule{{Match: "example.com", Max: "patch"}}},
+			src:    fakeVersions{versions: []string{"1.4.0", "1.3.0"}},
+			held:   "only patch updates allowed",
+		},
+		{
+			name:   "first matching rule applies",
+			config: Config{Rules: []Rule{{Match: "example.com/lib", Max: "minor"}, {Match: "example.com", Max: "patch"}}},
+			want:   "1.4.0", updatable: true,
+		},
+		{
+			name:   "pin",
+			config: Config{Pin: map[string]string{"example.com/lib": "~1.3"}},
+			want:   "1.3.1", held: "pinned to ~1.3", updatable: true,
+		},
+		{
+			name:   "prerelease opt-in",
+			config: Config{Prerelease: true},
+			want:   "1.5.0-rc.1", updatable: true, wantLatest: "1.5.0-rc.1",
+		},
+		{
+			name:   "prerelease opt-in by rule",
+			config: Config{Rules: []Rule{{Match: "example.com", Prerelease: &yes}}},
+			want:   "1.5.0-rc.1", updatable: true, wantLatest: "1.5.0-rc.1",
+		},
+		{
+			name:   "unknown release time held back under min age",
+			config: Config{MinAge: "14d"},
+			want:   "1.3.1", held: "release time unknown, cannot check the 14d cooldown", updatable: true,
+		},
+		{
+			name:   "only unknown release times under min age",
+			config: Config{MinAge: "14d"},
+			src:    fakeVersions{versions: []string{"1.4.0", "1.3.0"}},
+			held:   "release time unknown",
+		},
+		{
+			name:   "young release under min age",
+			config: Config{MinAge: "14d"},
+			src: fakeVersions{
+				versions: []string{"1.4.0", "1.3.0"},
+				released: map[string]time.Time{"1.4.0": fresh, "1.3.0": old},
+			},
+			want: "1.3.0", held: "younger than 14d", updatable: true,
+		},
+		{
+			name:   "old release under min age",
+			config: Config{MinAge: "14d"},
+			src:    fakeVersions{versions: []string{"1.4.0"}, released: map[string]time.Time{"1.4.0": old}},
+			want:   "1.4.0", updatable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.compile(); err != nil {
+				t.Fatal(err)
+			}
+			s := tt.src
+			if s.versions == nil {
+				s = src
+			}
+			m := Module{
+				Path:      "example.com/lib",
+				Current:   semver.MustParse("1.2.0"),
+				Latest:    semver.MustParse("1.4.0"),
+				Updatable: true,
+			}
+
+			got := tt.config.Policy(m.Path).Apply(m, s)
+			if got.Updatable != tt.updatable {
+				t.Fatalf("updatable = %t, want %t (held: %q)", got.Updatable, tt.updatable, got.Held)
+			}
+			if tt.updatable && got.TargetVersion().String() != tt.want {
+				t.Errorf("target = %s, want %s", got.TargetVersion(), tt.want)
+			}
+			if tt.wantLatest != "" && got.Latest.String() != tt.wantLatest {
+				t.Errorf("latest = %s, want %s", got.Latest, tt.wantLatest)
+			}
+			switch {
+			case tt.held == "" && got.Held != "":
+				t.Errorf("held = %q, want none", got.Held)
+			case !strings.Contains(got.Held, tt.held):
+				t.Errorf("held = %q, want it to contain %q", got.Held, tt.held)
+			}
+		})
+	}
+}
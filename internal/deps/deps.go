@@ -1,10 +1,14 @@
 package deps
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"golang.org/x/mod/modfile"
@@ -13,31 +17,88 @@ import (
 // Module describes a Go module upgrade candidate
 type Module struct {
 	Path           string
+	Root           Root
+	TargetPath     string // module path of Latest when it differs from Path (major upgrades)
 	Current        *semver.Version
 	Latest         *semver.Version
+	LatestTime     time.Time       // release time of Latest, zero when unknown
+	Target         *semver.Version // version picked instead of Latest, nil to upgrade to Latest
+	TargetTime     time.Time       // release time of Target, zero when unknown
 	IsTool         bool
-	UpdateCategory string // "minor" | "patch" | "prerelease" | "metadata"
+	Indirect       bool
+	Retracted      []string // rationale of retracting Current, if it is retracted
+	Deprecated     string   // deprecation notice of the module, if any
+	Held           string   // reason the project config holds back Latest, if it does
+	Compat         *APIDiff // exported API changes up to the target version, nil until computed
+	Usage          *Usage   // packages of Root importing the module, nil until analyzed
+	UpdateCategory string   // "major" | "minor" | "patch" | "prerelease" | "metadata"
 	Updatable      bool
 }
 
+// UpgradePath returns the module path Latest is published under
+func (m Module) UpgradePath() string {
+	if m.TargetPath != "" {
+		return m.TargetPath
+	}
+	return m.Path
+}
+
+// TargetVersion returns the version the module is upgraded to
+func (m Module) TargetVersion() *semver.Version {
+	if m.Target != nil {
+		return m.Target
+	}
+	return m.Latest
+}
+
+// TargetReleaseTime returns the release time of TargetVersion, zero when unknown
+func (m Module) TargetReleaseTime() time.Time {
+	if m.Target != nil {
+		return m.TargetTime
+	}
+	return m.LatestTime
+}
+
+// WithTarget returns m upgrading to v instead of Latest
+func (m Module) WithTarget(v *semver.Version) Module {
+	m.Target, m.TargetTime, m.Compat = v, time.Time{}, nil
+	if v != nil && v.Equal(m.Latest) {
+		m.Target = nil
+	}
+	m.UpdateCategory = categorize(m.Current, m.TargetVersion())
+	return m
+}
+
+// BreaksClaim reports whether the update category promises compatibility
+// while the API diff found breaking changes. v0 versions promise nothing.
+func (m Module) BreaksClaim() bool {
+	return m.Compat != nil && !m.Compat.Compatible() &&
+		m.UpdateCategory != "major" && m.Current != nil && m.Current.Major() > 0
+}
+
 // goListModule mirrors a subset of fields from `go list -u -m -json` output
 type goListModule struct {
-	Path     string `json:"Path"`
-	Version  string `json:"Version"`
-	Indirect bool   `json:"Indirect"`
-	Main     bool   `json:"Main"`
-	Update   *struct {
-		Path    string `json:"Path"`
-		Version string `json:"Version"`
+	Path       string    `json:"Path"`
+	Version    string    `json:"Version"`
+	Indirect   bool      `json:"Indirect"`
+	Main       bool      `json:"Main"`
+	Retracted  []string  `json:"Retracted"`
+	Deprecated string    `json:"Deprecated"`
+	Versions   []string  `json:"Versions"`
+	Time       time.Time `json:"Time"`
+	Update     *struct {
+		Path    string    `json:"Path"`
+		Version string    `json:"Version"`
+		Time    time.Time `json:"Time"`
 	} `json:"Update"`
+	Error *struct {
+		Err string `json:"Err"`
+	} `json:"Error"`
 }
 
-// ListAllModulePaths returns all direct (non-indirect) module paths declared in go.mod
-func ListAllModulePaths() ([]string, error) {
-	gomodPath, err := getGoModPath()
-	if err != nil {
-		return nil, err
-	}
+// ListAllModulePaths returns module requirements declared in go.mod of root that opts includes
+func ListAllModulePaths(root Root, opts ScanOptions) ([]Requirement, error) {
+	gomodPath := filepath.Join(root.Dir, "go.mod")
 
 	data, err := os.ReadFile(gomodPath)
 	if err != nil {
@@ -49,26 +110,83 @@ func ListAllModulePaths() ([]string, error) {
 		return nil, err
 	}
 
-	paths := make([]string, 0, len(f.Require))
+	tools := toolModules(f)
+	reqs := make([]Requirement, 0, len(f.Require))
 	seen := make(map[string]struct{})
 	for _, req := range f.Require {
-		if req == nil || req.Mod.Path == "" || req.Indirect {
+		if req == nil || req.Mod.Path == "" {
 			continue
 		}
 		if _, ok := seen[req.Mod.Path]; ok {
 			continue
 		}
-		paths = append(paths, req.Mod.Path)
+		r := Requirement{
+			Root:     root,
+			Path:     req.Mod.Path,
+			Version:  req.Mod.Version,
+			Indirect: req.Indirect,
+			IsTool:   tools[req.Mod.Path],
+		}
+		if !opts.Includes(r) {
+			continue
+		}
+		if opts.Config.Ignored(r.Path) {
+			r.Held = "ignored by config"
+		}
+		reqs = append(reqs, r)
 		seen[req.Mod.Path] = struct{}{}
 	}
 
-	return paths, nil
+	return reqs, nil
 }
 
-func getGoModPath() (string, error) {
-	cmd := exec.Command("go", "env", "GOMOD")
+// toolModules returns paths of required modules providing packages of `tool` directives
+func toolModules(f *modfile.File) map[string]bool {
+	owners := make(map[string]bool, len(f.Tool))
+	for _, tool := range f.Tool {
+		if tool == nil {
+			continue
+		}
+
+		// the owning module is the longest required path prefixing the tool package
+		owner := ""
+		for _, req := range f.Require {
+			p := req.Mod.Path
+			if len(p) > len(owner) && (tool.Path == p || strings.HasPrefix(tool.Path, p+"/")) {
+				owner = p
+			}
+		}
+		if owner != "" {
+			owners[owner] = true
+		}
+	}
+
+	return owners
+}
+
+// goCommand prepares the go command with args in root, resolving modules of root
+// alone rather than of a go.work workspace around it
+func goCommand(root Root, args ...string) *exec.Cmd {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = root.Dir
 	cmd.Env = append(os.Environ(), "GOWORK=off")
-	out, err := cmd.Output()
+	return cmd
+}
+
+// goOutput runs goCommand and returns its standard output, errors carry its standard error
+func goOutput(root Root, args ...string) ([]byte, error) {
+	out, err := goCommand(root, args...).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func getGoModPath() (string, error) {
+	out, err := goCommand(Root{}, "env", "GOMOD").Output()
 	if err != nil {
 		return "", err
 	}
@@ -79,35 +197,90 @@ func getGoModPath() (string, error) {
 	return path, nil
 }
 
-func GetModuleInfo(path string) (Module, error) {
-	cmd := exec.Command("go", "list", "-m", "-u", "-mod=readonly", "-json", path)
-	cmd.Env = append(os.Environ(), "GOWORK=off")
-	out, err := cmd.Output()
+// GetModuleInfo queries available updates of req within its owning module
+func GetModuleInfo(req Requirement) (Module, error) {
+	out, err := goOutput(req.Root, "list", "-m", "-u", "-mod=readonly", "-json", req.Path)
 	if err != nil {
-		return Module{Path: path}, err
+		return newModule(req), err
 	}
 
 	var m goListModule
 	if e := json.Unmarshal(out, &m); e != nil {
-		return Module{Path: path}, e
+		return newModule(req), e
 	}
-	if m.Main || m.Indirect || m.Update == nil || m.Update.Version == "" {
-		return Module{Path: path}, nil
+
+	return moduleFromList(req, m), nil
+}
+
+// GetModulesInfo is GetModuleInfo for requirements of a single root in one `go list` invocation.
+// The returned modules follow the order of reqs.
+func GetModulesInfo(root Root, reqs []Requirement) ([]Module, error) {
+	args := []string{"list", "-m", "-u", "-mod=readonly", "-json"}
+	for _, req := range reqs {
+		args = append(args, req.Path)
+	}
+
+	out, err := goOutput(root, args...)
+	if err != nil {
+		return nil, err
 	}
 
-	fromV, e1 := semver.NewVersion(stripV(m.Version))
-	toV, e2 := semver.NewVersion(stripV(m.Update.Version))
-	if e1 != nil || e2 != nil {
-		return Module{Path: path}, nil
+	// go list prints concatenated JSON objects rather than an array
+	listed := make(map[string]goListModule, len(reqs))
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var m goListModule
+		if err := dec.Decode(&m); err != nil {
+			return nil, err
+		}
+		listed[m.Path] = m
 	}
 
-	return Module{
-		Path:           m.Path,
-		Current:        fromV,
-		Latest:         toV,
-		UpdateCategory: categorize(fromV, toV),
-		Updatable:      true,
-	}, nil
+	mods := make([]Module, 0, len(reqs))
+	for _, req := range reqs {
+		m, ok := listed[req.Path]
+		if !ok {
+			return nil, fmt.Errorf("go list: no result for %s", req.Path)
+		}
+		mods = append(mods, moduleFromList(req, m))
+	}
+
+	return mods, nil
+}
+
+func newModule(req Requirement) Module {
+	return Module{Path: req.Path, Root: req.Root, Indirect: req.Indirect, IsTool: req.IsTool}
+}
+
+// moduleFromList converts a `go list -m -u` entry for req into an upgrade candidate
+func moduleFromList(req Requirement, m goListModule) Module {
+	mod := newModule(req)
+	if m.Main {
+		return mod
+	}
+
+	fromV, err := semver.NewVersion(stripV(m.Version))
+	if err != nil {
+		return mod
+	}
+	mod.Current = fromV
+	mod.Retracted = m.Retracted
+	mod.Deprecated = m.Deprecated
+	if m.Update == nil || m.Update.Version == "" {
+		return mod
+	}
+
+	toV, err := semver.NewVersion(stripV(m.Update.Version))
+	if err != nil {
+		return mod
+	}
+
+	mod.Latest = toV
+	mod.LatestTime = m.Update.Time
+	mod.UpdateCategory = categorize(fromV, toV)
+	mod.Updatable = true
+
+	return mod
 }
 
 func stripV(v string) string {
@@ -0,0 +1,162 @@
+package deps
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around changes in a unified diff
+const diffContext = 3
+
+// edit is a line of a line diff, op is ' ', '-' or '+'
+type edit struct {
+	op   byte
+	line string
+}
+
+// UnifiedDiff returns the unified diff turning before into after, empty when they are equal
+func UnifiedDiff(name, before, after string) string {
+	if before == after {
+		return ""
+	}
+	edits := diffLines(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+
+	// positions of each edit in both files, 1-based
+	aLine, bLine := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	aLine[0], bLine[0] = 1, 1
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.op != '+' {
+			aLine[i+1]++
+		}
+		if e.op != '-' {
+			bLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// a hunk spans changes less than two contexts apart
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext, len(edits))
+
+		aCount, bCount := aLine[end]-aLine[start], bLine[end]-bLine[start]
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(aLine[start], aCount), hunkRange(bLine[start], bCount))
+		for _, e := range edits[start:end] {
+			b.WriteByte(e.op)
+			b.WriteString(e.line)
+			b.WriteByte('\n')
+		}
+		i = end
+	}
+
+	return b.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// an empty range names the line before it
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits s into lines, a last line without newline carries the marker
+// of unified diffs, so it differs from the same line with one
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if !strings.HasSuffix(s, "\n") {
+		lines[len(lines)-1] += "\n\\ No newline at end of file"
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b with the Myers algorithm
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down: insertion
+			} else {
+				x = v[offset+k-1] + 1 // right: deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backtrack walks trace back from the end of both files, trace[d] holding
+// the furthest reaching paths before step d
+func backtrack(a, b []string, trace [][]int, offset int) []edit {
+	x, y := len(a), len(b)
+	var edits []edit
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY && x > 0 && y > 0 {
+			edits = append(edits, edit{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			edits = append(edits, edit{'+', b[prevY]})
+		} else {
+			edits = append(edits, edit{'-', a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
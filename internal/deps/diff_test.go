@@ -0,0 +1,82 @@
+package deps
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numbered returns lines 1 to n, with replaced lines swapped in
+func numbered(n int, replaced map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replaced[i]
+		if !ok {
+			line = strconv.Itoa(i)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string // without the file header
+	}{
+		{name: "equal", before: "a\nb\n", after: "a\nb\n"},
+		{
+			name:   "change",
+			before: "a\nb\nc\n", after: "a\nB\nc\n",
+			want: "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:   "from empty",
+			before: "", after: "a\nb\n",
+			want: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:   "to empty",
+			before: "a\nb\n", after: "",
+			want: "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:   "insert single line",
+			before: "a\nc\n", after: "a\nb\nc\n",
+			want: "@@ -1,2 +1,3 @@\n a\n+b\n c\n",
+		},
+		{
+			name:   "two hunks",
+			before: numbered(20, nil), after: numbered(20, map[int]string{2: "two", 18: "eighteen"}),
+			want: "@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+		{
+			name:   "changes two contexts apart share a hunk",
+			before: numbered(12, nil), after: numbered(12, map[int]string{2: "two", 9: "nine"}),
+			want: "@@ -1,12 +1,12 @@\n 1\n-2\n+two\n 3\n 4\n 5\n 6\n 7\n 8\n-9\n+nine\n 10\n 11\n 12\n",
+		},
+		{
+			name:   "newline added at end",
+			before: "a\nb", after: "a\nb\n",
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:   "newline removed at end",
+			before: "a\nb\n", after: "a\nb",
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want != "" {
+				want = "--- a/go.mod\n+++ b/go.mod\n" + want
+			}
+			if got := UnifiedDiff("go.mod", tt.before, tt.after); got != want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Vendored reports whether root vendors its dependencies
+func Vendored(root Root) bool {
+	_, err := os.Stat(filepath.Join(root.Dir, "vendor", "modules.txt"))
+	return err == nil
+}
+
+// FinishSteps lists the commands bringing root in order once its upgrades are applied:
+// go mod tidy with tidy, and go mod vendor when root vendors its dependencies
+func FinishSteps(root Root, tidy bool) [][]string {
+	var steps [][]string
+	if tidy {
+		steps = append(steps, []string{"go", "mod", "tidy"})
+	}
+	if Vendored(root) {
+		steps = append(steps, []string{"go", "mod", "vendor"})
+	}
+	return steps
+}
+
+// RunFinishStep runs a step of FinishSteps in root, failures are returned as *VerifyError
+func RunFinishStep(root Root, step []string) error {
+	return runCommand(root, step, "GOWORK=off")
+}
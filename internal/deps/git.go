@@ -0,0 +1,259 @@
+package deps
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultCommitMessage is the template of commit messages created for upgrades
+const DefaultCommitMessage = "deps: bump {{.Path}} {{.From}} -> {{.To}}"
+
+// CommitData is what a commit message template is executed with
+type CommitData struct {
+	Path       string // module path before the upgrade
+	TargetPath string // module path after the upgrade, differs from Path for majors
+	From       string // version before the upgrade, e.g. v1.2.3
+	To         string // version after the upgrade
+	Category   string // update category, e.g. minor
+}
+
+// ParseCommitMessage parses a commit message template executed with CommitData
+func ParseCommitMessage(text string) (*template.Template, error) {
+	tmpl, err := template.New("commit").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("commit message template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// CheckModFilesClean fails when go.mod or go.sum of root have uncommitted changes,
+// which would end up in the commit of the first upgrade
+func CheckModFilesClean(root Root) error {
+	out, err := git(root, "status", "--porcelain", "--", "go.mod", "go.sum")
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		return fmt.Errorf("go.mod of %s has uncommitted changes, commit or stash them first", root.Path)
+	}
+	return nil
+}
+
+// CommitUpgrade commits go.mod, go.sum, the vendor directory when root vendors its
+// dependencies and files with rewritten imports after m was upgraded
+func CommitUpgrade(m Module, files []string, tmpl *template.Template) error {
+	var msg bytes.Buffer
+	data := CommitData{
+		Path:       m.Path,
+		TargetPath: m.UpgradePath(),
+		To:         "v" + m.TargetVersion().String(),
+		Category:   m.UpdateCategory,
+	}
+	if m.Current != nil {
+		data.From = "v" + m.Current.String()
+	}
+	if err := tmpl.Execute(&msg, data); err != nil {
+		return fmt.Errorf("commit message of %s: %w", m.Path, err)
+	}
+
+	paths := append([]string{"go.mod"}, files...)
+	if _, err := os.Stat(filepath.Join(m.Root.Dir, "go.sum")); err == nil {
+		// go.sum is missing without requirements and git add fails on unknown paths
+		paths = append(paths, "go.sum")
+	}
+	if Vendored(m.Root) {
+		paths = append(paths, "vendor")
+	}
+	if _, err := git(m.Root, append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return err
+	}
+	// listing the paths leaves changes the user staged before out of the commit
+	if _, err := git(m.Root, append([]string{"commit", "--quiet", "--message", msg.String(), "--"}, paths...)...); err != nil {
+		if _, rerr := git(m.Root, append([]string{"reset", "--quiet", "--"}, paths...)...); rerr != nil {
+			return fmt.Errorf("%w\nunstage: %v", err, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// UpgradeCommit upgrades m like Upgrade, runs its FinishSteps and commits the result with
+// CommitUpgrade. When finishing or committing fails, go.mod, go.sum, vendor and rewritten
+// imports are restored and a *RollbackError is returned.
+func UpgradeCommit(m Module, opts UpgradeOptions, tidy bool, tmpl *template.Template) ([]string, error) {
+	snap, err := snapshotModFiles(m.Root)
+	if err != nil {
+		return nil, err
+	}
+	rw, err := upgradeChecked(m, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = finishAndCommit(m, rw.Files(), tidy, tmpl)
+	if err == nil {
+		return rw.Files(), nil
+	}
+	if rerr := snap.restore(); rerr != nil {
+		return rw.Files(), fmt.Errorf("%w\nrestore go.mod: %v", err, rerr)
+	}
+	if rerr := rw.restore(); rerr != nil {
+		return rw.Files(), fmt.Errorf("%w\nrestore imports of %s: %v", err, m.Path, rerr)
+	}
+	if Vendored(m.Root) {
+		// vendor is generated, so HEAD has what go mod vendor would produce before the upgrade
+		if _, rerr := git(m.Root, "checkout", "--quiet", "HEAD", "--", "vendor"); rerr != nil {
+			return rw.Files(), fmt.Errorf("%w\nrestore vendor: %v", err, rerr)
+		}
+		if _, rerr := git(m.Root, "clean", "--quiet", "--force", "-d", "--", "vendor"); rerr != nil {
+			return rw.Files(), fmt.Errorf("%w\nrestore vendor: %v", err, rerr)
+		}
+	}
+
+	return nil, &RollbackError{Err: err}
+}
+
+func finishAndCommit(m Module, files []string, tidy bool, tmpl *template.Template) error {
+	for _, step := range FinishSteps(m.Root, tidy) {
+		if err := RunFinishStep(m.Root, step); err != nil {
+			return err
+		}
+	}
+	return CommitUpgrade(m, files, tmpl)
+}
+
+func git(root Root, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = root.Dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v\n%s", strings.Join(args, " "), err, string(out))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// refUnsafe matches characters of module paths and versions that ref names should not hold
+var refUnsafe = regexp.MustCompile(`[^A-Za-z0-9._+-]`)
+
+// BranchName returns the branch an upgrade of m is committed on with the branch strategy.
+// Parts of the name git refuses in refs, such as ~, ".." or a ".lock" suffix, are replaced.
+func BranchName(m Module) string {
+	parts := strings.Split("modup/"+m.UpgradePath()+"-v"+m.TargetVersion().String(), "/")
+	for i, part := range parts {
+		part = refUnsafe.ReplaceAllString(part, "-")
+		for strings.Contains(part, "..") {
+			part = strings.ReplaceAll(part, "..", ".")
+		}
+		part = strings.TrimSuffix(strings.TrimLeft(part, "."), ".")
+		if base, ok := strings.CutSuffix(part, ".lock"); ok {
+			part = base + "-lock"
+		}
+		if part == "" {
+			part = "-"
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "/")
+}
+
+// OnBranch runs fn on a new branch name off HEAD of the repository of root and switches back
+// afterwards. When fn fails, every file it changed is reset and the branch is deleted.
+func OnBranch(root Root, name string, fn func() error) error {
+	head, err := git(root, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		// detached HEAD
+		if head, err = git(root, "rev-parse", "HEAD"); err != nil {
+			return err
+		}
+	}
+	before, err := changedFiles(root)
+	if err != nil {
+		return err
+	}
+	if _, err := git(root, "checkout", "--quiet", "-b", name); err != nil {
+		return err
+	}
+
+	ferr := fn()
+	if ferr != nil {
+		if err := resetChanges(root, before); err != nil {
+			ferr = fmt.Errorf("%w\nreset changes: %v", ferr, err)
+		}
+	}
+	if _, err := git(root, "checkout", "--quiet", head); err != nil {
+		if ferr != nil {
+			return fmt.Errorf("%w\nswitch back to %s: %v", ferr, head, err)
+		}
+		return fmt.Errorf("switch back to %s: %w", head, err)
+	}
+	if ferr != nil {
+		if _, err := git(root, "branch", "--quiet", "-D", name); err != nil {
+			return fmt.Errorf("%w\ndelete branch: %v", ferr, err)
+		}
+	}
+	return ferr
+}
+
+// worktreeChanges are files of root differing from HEAD, relative to root.Dir
+type worktreeChanges struct {
+	tracked   map[string]bool
+	untracked map[string]bool
+}
+
+func changedFiles(root Root) (worktreeChanges, error) {
+	c := worktreeChanges{tracked: make(map[string]bool), untracked: make(map[string]bool)}
+	tracked, err := git(root, "diff", "-z", "--name-only", "--relative", "HEAD")
+	if err != nil {
+		return c, err
+	}
+	untracked, err := git(root, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return c, err
+	}
+	for _, path := range strings.Split(tracked, "\x00") {
+		if path != "" {
+			c.tracked[path] = true
+		}
+	}
+	for _, path := range strings.Split(untracked, "\x00") {
+		if path != "" {
+			c.untracked[path] = true
+		}
+	}
+	return c, nil
+}
+
+// resetChanges checks out files changed since before from HEAD and removes new files.
+// Files changed before are left alone, they hold edits of the user.
+func resetChanges(root Root, before worktreeChanges) error {
+	after, err := changedFiles(root)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for path := range after.tracked {
+		if !before.tracked[path] {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) > 0 {
+		if _, err := git(root, append([]string{"checkout", "--quiet", "HEAD", "--"}, paths...)...); err != nil {
+			return err
+		}
+	}
+	for path := range after.untracked {
+		if before.untracked[path] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(root.Dir, path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package deps
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestBranchName(t *testing.T) {
+	tests := []struct {
+		path, target string
+		major        string
+		want         string
+	}{
+		{path: "github.com/pkg/errors", target: "0.9.1", want: "modup/github.com/pkg/errors-v0.9.1"},
+		{path: "example.com/a", major: "example.com/a/v2", target: "2.0.0", want: "modup/example.com/a/v2-v2.0.0"},
+		{path: "example.com/~user/mod", target: "1.0.0", want: "modup/example.com/-user/mod-v1.0.0"},
+		{path: "example.com/file.lock", target: "1.0.0", want: "modup/example.com/file.lock-v1.0.0"},
+		{path: "example.com/pkg", target: "1.0.0-rc.1.lock", want: "modup/example.com/pkg-v1.0.0-rc.1-lock"},
+		{path: "example.com/a..b/.hidden", target: "1.0.0+build", want: "modup/example.com/a.b/hidden-v1.0.0+build"},
+	}
+	_, gitErr := exec.LookPath("git")
+	for _, tt := range tests {
+		m := Module{Path: tt.path, TargetPath: tt.major, Current: semver.MustParse("0.1.0"), Latest: semver.MustParse(tt.target)}
+		got := BranchName(m)
+		if got != tt.want {
+			t.Errorf("BranchName(%s@%s) = %q, want %q", m.UpgradePath(), tt.target, got, tt.want)
+		}
+		if gitErr == nil {
+			if out, err := exec.Command("git", "check-ref-format", "--branch", got).CombinedOutput(); err != nil {
+				t.Errorf("git refuses branch %q: %s", got, out)
+			}
+		}
+	}
+}
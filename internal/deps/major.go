@@ -0,0 +1,363 @@
+package deps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// maxMajorProbes bounds the number of successive /vN paths probed for a single module
+const maxMajorProbes = 20
+
+// FindMajorUpgrade probes module paths of higher major versions of mod
+// (`/v2`, `/v3`, ... or `.v2` for gopkg.in) and returns the newest one published.
+// Go never proposes those via `go list -m -u` since they are distinct modules.
+func FindMajorUpgrade(mod Module) (Module, bool) {
+	major, ok := FindMajorUpgrades(mod.Root, []Module{mod})[mod.Path]
+	return major, ok
+}
+
+// FindMajorUpgrades is FindMajorUpgrade for modules of a single root. Every probing step
+// queries the next major of all remaining modules in one `go list` invocation.
+// The result is keyed by module path.
+func FindMajorUpgrades(root Root, mods []Module) map[string]Module {
+	return findMajorUpgrades(mods, func(paths []string) map[string]latestVersion {
+		return queryLatest(root, paths)
+	})
+}
+
+// latestVersion is the latest version of a module path and its release time
+type latestVersion struct {
+	version *semver.Version
+	time    time.Time
+}
+
+// findMajorUpgrades probes successive major module paths of mods,
+// resolving latest versions of a set of paths with query
+func findMajorUpgrades(mods []Module, query func(paths []string) map[string]latestVersion) map[string]Module {
+	type probe struct {
+		mod    Module
+		prefix string
+		major  uint64
+	}
+
+	var probes []probe
+	for _, mod := range mods {
+		if mod.Current == nil {
+			continue
+		}
+		prefix, _, ok := module.SplitPathVersion(mod.Path)
+		if !ok {
+			continue
+		}
+		probes = append(probes, probe{mod: mod, prefix: prefix, major: max(mod.Current.Major()+1, 2)})
+	}
+
+	found := make(map[string]Module)
+	for step := 0; step < maxMajorProbes && len(probes) > 0; step++ {
+		paths := make([]string, 0, len(probes))
+		for _, p := range probes {
+			paths = append(paths, majorPath(p.prefix, p.major))
+		}
+		latest := query(paths)
+
+		next := probes[:0]
+		for _, p := range probes {
+			path := majorPath(p.prefix, p.major)
+			l, ok := latest[path]
+			if !ok {
+				continue
+			}
+
+			found[p.mod.Path] = Module{
+				Path:           p.mod.Path,
+				Root:           p.mod.Root,
+				TargetPath:     path,
+				Indirect:       p.mod.Indirect,
+				IsTool:         p.mod.IsTool,
+				Current:        p.mod.Current,
+				Latest:         l.version,
+				LatestTime:     l.time,
+				UpdateCategory: "major",
+				Updatable:      true,
+			}
+			p.major++
+			next = append(next, p)
+		}
+		probes = next
+	}
+
+	return found
+}
+
+func majorPath(prefix string, major uint64) string {
+	if strings.HasPrefix(prefix, "gopkg.in/") {
+		return fmt.Sprintf("%s.v%d", prefix, major)
+	}
+	return fmt.Sprintf("%s/v%d", prefix, major)
+}
+
+// queryLatest resolves latest versions of module paths that may not be required yet.
+// Paths without any published version are absent from the result.
+func queryLatest(root Root, paths []string) map[string]latestVersion {
+	args := []string{"list", "-m", "-e", "-json"}
+	for _, p := range paths {
+		args = append(args, p+"@latest")
+	}
+
+	out, _ := goCommand(root, args...).Output() // -e reports per-module failures in the Error field
+
+	latest := make(map[string]latestVersion, len(paths))
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var m goListModule
+		if err := dec.Decode(&m); err != nil {
+			break
+		}
+		if m.Error != nil {
+			continue
+		}
+		if v, err := semver.NewVersion(stripV(m.Version)); err == nil {
+			latest[m.Path] = latestVersion{version: v, time: m.Time}
+		}
+	}
+
+	return latest
+}
+
+// upgradeMajor switches the module at m.Root from m.Path to m.TargetPath:
+// it rewrites imports in every .go file and swaps the require line in go.mod.
+// When that fails, go.mod and the rewritten files are restored.
+func upgradeMajor(m Module) (importRewrite, error) {
+	gomodPath := filepath.Join(m.Root.Dir, "go.mod")
+	gomod, err := os.ReadFile(gomodPath)
+	if err != nil {
+		return importRewrite{}, err
+	}
+
+	rw, err := rewriteImports(m.Root.Dir, m.Path, m.TargetPath)
+	if err != nil {
+		return importRewrite{}, fmt.Errorf("rewrite imports of %s: %w", m.Path, err)
+	}
+
+	err = swapRequire(gomodPath, gomod, m.Path, m.TargetPath, "v"+m.TargetVersion().String())
+	if err == nil {
+		// resolves the new requirement graph and go.sum entries
+		err = goGet(m.Root, m.TargetPath+"@v"+m.TargetVersion().String())
+	}
+	if err != nil {
+		if werr := os.WriteFile(gomodPath, gomod, 0o644); werr != nil {
+			return rw, fmt.Errorf("%w\nrestore go.mod: %v", err, werr)
+		}
+		if rerr := rw.restore(); rerr != nil {
+			return rw, fmt.Errorf("%w\nrestore imports of %s: %v", err, m.Path, rerr)
+		}
+		return importRewrite{}, err
+	}
+
+	return rw, nil
+}
+
+// swapRequire replaces the requirement on oldPath with a direct requirement on newPath@version.
+// A requirement on newPath the project had already is updated instead of repeated.
+func swapRequire(gomodPath string, data []byte, oldPath, newPath, version string) error {
+	f, err := modfile.Parse(gomodPath, data, nil)
+	if err != nil {
+		return err
+	}
+	if err := f.DropRequire(oldPath); err != nil {
+		return err
+	}
+	if err := f.AddRequire(newPath, version); err != nil {
+		return err
+	}
+	f.Cleanup()
+
+	out, err := f.Format()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(gomodPath, out, 0o644)
+}
+
+// importRewrite is a set of files whose imports were moved to another module path.
+// It keeps their content from before, so restoring it leaves imports of the new
+// path that existed before the rewrite alone.
+type importRewrite struct {
+	dir   string
+	files []rewrittenFile
+}
+
+type rewrittenFile struct {
+	rel  string // relative to dir
+	src  []byte // content before the rewrite
+	perm fs.FileMode
+}
+
+// Files returns the rewritten files relative to the module directory
+func (r importRewrite) Files() []string {
+	var files []string
+	for _, f := range r.files {
+		files = append(files, f.rel)
+	}
+	return files
+}
+
+// restore puts back the content the files had before the rewrite
+func (r importRewrite) restore() error {
+	for _, f := range r.files {
+		if err := os.WriteFile(filepath.Join(r.dir, f.rel), f.src, f.perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreRewrites restores rewrites in reverse order, a file rewritten by several
+// of them ends up with the content it had before the first one
+func restoreRewrites(rws []importRewrite) error {
+	for i := len(rws) - 1; i >= 0; i-- {
+		if err := rws[i].restore(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteImports replaces imports of oldPath and its packages with newPath in every
+// .go file of the module at dir. Only the import path literals are edited, so the
+// rest of each file keeps its formatting. Every file is parsed before the first one
+// is written, and files already written are restored when a later write fails.
+func rewriteImports(dir, oldPath, newPath string) (importRewrite, error) {
+	type pending struct {
+		file rewrittenFile
+		out  []byte
+	}
+	var edits []pending
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// nested modules own their imports
+			if outsideModule(dir, path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, changed, err := rewriteFileImports(path, src, oldPath, newPath)
+		if err != nil || !changed {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		edits = append(edits, pending{file: rewrittenFile{rel: rel, src: src, perm: info.Mode().Perm()}, out: out})
+
+		return nil
+	})
+	if err != nil {
+		return importRewrite{}, err
+	}
+
+	rw := importRewrite{dir: dir}
+	for _, e := range edits {
+		if err := os.WriteFile(filepath.Join(dir, e.file.rel), e.out, e.file.perm); err != nil {
+			if rerr := rw.restore(); rerr != nil {
+				return importRewrite{}, fmt.Errorf("%w\nrestore %s: %v", err, dir, rerr)
+			}
+			return importRewrite{}, err
+		}
+		rw.files = append(rw.files, e.file)
+	}
+
+	return rw, nil
+}
+
+// rewriteFileImports returns src of filename with imports of oldPath moved to newPath
+func rewriteFileImports(filename string, src []byte, oldPath, newPath string) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		rest, ok := importSuffix(importPath, oldPath)
+		if !ok {
+			continue
+		}
+		edits = append(edits, edit{
+			start: fset.Position(spec.Path.Pos()).Offset,
+			end:   fset.Position(spec.Path.End()).Offset,
+			text:  strconv.Quote(newPath + rest),
+		})
+	}
+	if len(edits) == 0 {
+		return nil, false, nil
+	}
+
+	// apply from the end so earlier offsets stay valid, on a copy as src is kept for restoring
+	out := append([]byte(nil), src...)
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+
+	return out, true, nil
+}
+
+// importSuffix reports whether importPath is a package of module modPath and returns
+// the package subpath. Packages of other major versions (modPath/vN) are not matched.
+func importSuffix(importPath, modPath string) (string, bool) {
+	if importPath == modPath {
+		return "", true
+	}
+	if !strings.HasPrefix(importPath, modPath+"/") {
+		return "", false
+	}
+
+	rest := importPath[len(modPath):]
+	first, _, _ := strings.Cut(rest[1:], "/")
+	if _, pathMajor, ok := module.SplitPathVersion(modPath + "/" + first); ok && pathMajor != "" {
+		return "", false
+	}
+
+	return rest, true
+}
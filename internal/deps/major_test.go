@@ -0,0 +1,40 @@
+package deps
+
+import "testing"
+
+func TestRewriteFileImports(t *testing.T) {
+	tests := []struct {
+		name             string
+		oldPath, newPath string
+		imp              string
+		want             string // empty when the import stays unchanged
+	}{
+		{name: "module root", oldPath: "example.com/a", newPath: "example.com/a/v2", imp: "example.com/a", want: "example.com/a/v2"},
+		{name: "package", oldPath: "example.com/a", newPath: "example.com/a/v2", imp: "example.com/a/sub", want: "example.com/a/v2/sub"},
+		{name: "path prefix", oldPath: "example.com/a", newPath: "example.com/a/v2", imp: "example.com/ab"},
+		{name: "already upgraded", oldPath: "example.com/a", newPath: "example.com/a/v2", imp: "example.com/a/v2/sub"},
+		{name: "major to major", oldPath: "example.com/a/v2", newPath: "example.com/a/v3", imp: "example.com/a/v2/sub", want: "example.com/a/v3/sub"},
+		{name: "gopkg.in", oldPath: "gopkg.in/yaml.v2", newPath: "gopkg.in/yaml.v3", imp: "gopkg.in/yaml.v2", want: "gopkg.in/yaml.v3"},
+		{name: "gopkg.in package", oldPath: "gopkg.in/yaml.v2", newPath: "gopkg.in/yaml.v3", imp: "gopkg.in/yaml.v2/sub", want: "gopkg.in/yaml.v3/sub"},
+		{name: "gopkg.in other major", oldPath: "gopkg.in/yaml.v2", newPath: "gopkg.in/yaml.v3", imp: "gopkg.in/yaml.v3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package p\n\nimport (\n\t\"fmt\"\n\tx \"" + tt.imp + "\"\n)\n"
+			got, changed, err := rewriteFileImports("p.go", []byte(src), tt.oldPath, tt.newPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if changed {
+					t.Errorf("import %q rewritten:\n%s", tt.imp, got)
+				}
+				return
+			}
+			want := "package p\n\nimport (\n\t\"fmt\"\n\tx \"" + tt.want + "\"\n)\n"
+			if !changed || string(got) != want {
+				t.Errorf("rewritten to:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"golang.org/x/mod/modfile"
+)
+
+// Preview is what upgrading modules of a main module would change in its go.mod and go.sum
+type Preview struct {
+	Root    Root
+	Diff    string          // unified diff of go.mod and go.sum
+	Changes []VersionChange // requirements whose version changes, by path
+}
+
+// VersionChange is a requirement whose version changes, From is empty for
+// added requirements and To for dropped ones
+type VersionChange struct {
+	Path     string
+	From, To string
+}
+
+// Kind describes the change: upgrade, downgrade, added or removed
+func (c VersionChange) Kind() string {
+	switch {
+	case c.From == "":
+		return "added"
+	case c.To == "":
+		return "removed"
+	}
+	from, ferr := semver.NewVersion(c.From)
+	to, terr := semver.NewVersion(c.To)
+	if ferr == nil && terr == nil && to.LessThan(from) {
+		return "downgrade"
+	}
+	return "upgrade"
+}
+
+// PreviewUpgrades runs `go get` for ms on copies of go.mod and go.sum of their main
+// modules, which are left untouched, and reports the differences per main module.
+// Imports are not rewritten for major upgrades, the requirement is swapped only.
+func PreviewUpgrades(ms []Module) ([]Preview, error) {
+	var roots []Root
+	byRoot := make(map[string][]Module)
+	for _, m := range ms {
+		if _, ok := byRoot[m.Root.Dir]; !ok {
+			roots = append(roots, m.Root)
+		}
+		byRoot[m.Root.Dir] = append(byRoot[m.Root.Dir], m)
+	}
+
+	previews := make([]Preview, 0, len(roots))
+	for _, root := range roots {
+		p, err := previewRoot(root, byRoot[root.Dir])
+		if err != nil {
+			return previews, fmt.Errorf("%s: %w", root.Path, err)
+		}
+		previews = append(previews, p)
+	}
+
+	return previews, nil
+}
+
+func previewRoot(root Root, ms []Module) (Preview, error) {
+	gomod, err := os.ReadFile(filepath.Join(root.Dir, "go.mod"))
+	if err != nil {
+		return Preview{}, err
+	}
+	gosum, err := os.ReadFile(filepath.Join(root.Dir, "go.sum"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Preview{}, err
+	}
+
+	tmp, err := os.MkdirTemp("", "modup-preview-")
+	if err != nil {
+		return Preview{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// go uses the .sum file next to the -modfile
+	tmpMod, tmpSum := filepath.Join(tmp, "go.mod"), filepath.Join(tmp, "go.sum")
+	if err := os.WriteFile(tmpMod, gomod, 0o644); err != nil {
+		return Preview{}, err
+	}
+	if err := os.WriteFile(tmpSum, gosum, 0o644); err != nil {
+		return Preview{}, err
+	}
+
+	queries := make([]string, 0, len(ms))
+	for _, m := range ms {
+		if m.TargetPath != "" && m.TargetPath != m.Path {
+			data, err := os.ReadFile(tmpMod)
+			if err != nil {
+				return Preview{}, err
+			}
+			if err := swapRequire(tmpMod, data, m.Path, m.TargetPath, "v"+m.TargetVersion().String()); err != nil {
+				return Preview{}, err
+			}
+		}
+		queries = append(queries, fmt.Sprintf("%s@v%s", m.UpgradePath(), m.TargetVersion()))
+	}
+
+	cmd := goCommand(root, append([]string{"get", "-modfile=" + tmpMod}, queries...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return Preview{}, fmt.Errorf("go get %s failed: %v\n%s", strings.Join(queries, " "), err, out)
+	}
+
+	newMod, err := os.ReadFile(tmpMod)
+	if err != nil {
+		return Preview{}, err
+	}
+	newSum, err := os.ReadFile(tmpSum)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Preview{}, err
+	}
+
+	changes, err := versionChanges(gomod, newMod)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	return Preview{
+		Root:    root,
+		Diff:    UnifiedDiff("go.mod", string(gomod), string(newMod)) + UnifiedDiff("go.sum", string(gosum), string(newSum)),
+		Changes: changes,
+	}, nil
+}
+
+// versionChanges compares requirements of two versions of a go.mod
+func versionChanges(before, after []byte) ([]VersionChange, error) {
+	oldReqs, err := requireVersions(before)
+	if err != nil {
+		return nil, err
+	}
+	newReqs, err := requireVersions(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []VersionChange
+	for path, from := range oldReqs {
+		if to := newReqs[path]; to != from {
+			changes = append(changes, VersionChange{Path: path, From: from, To: to})
+		}
+	}
+	for path, to := range newReqs {
+		if _, ok := oldReqs[path]; !ok {
+			changes = append(changes, VersionChange{Path: path, To: to})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return changes, nil
+}
+
+func requireVersions(gomod []byte) (map[string]string, error) {
+	f, err := modfile.ParseLax("go.mod", gomod, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string, len(f.Require))
+	for _, r := range f.Require {
+		versions[r.Mod.Path] = r.Mod.Version
+	}
+	return versions, nil
+}
@@ -0,0 +1,422 @@
+package deps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	modsemver "golang.org/x/mod/semver"
+)
+
+var (
+	// errNoProxy means a module has to be fetched from its origin, which only the go command can do
+	errNoProxy = errors.New("module is not served by a proxy")
+	// errNotFound means a proxy has no such module or version
+	errNotFound = errors.New("not found")
+)
+
+// ProxyClient resolves module versions by speaking the module proxy protocol
+// (https://go.dev/ref/mod#goproxy-protocol) directly instead of running the go command
+type ProxyClient struct {
+	proxies []proxyEntry
+	noProxy string // GONOPROXY patterns
+	http    *http.Client
+}
+
+type proxyEntry struct {
+	url string
+	// fallbackOnError tries the next entry on any error (`|` separator),
+	// otherwise only on 404 and 410 responses (`,` separator)
+	fallbackOnError bool
+}
+
+// VersionInfo is the JSON served by `@latest` and `@v/<version>.info` endpoints
+type VersionInfo struct {
+	Version string
+	Time    time.Time
+}
+
+// NewProxyClient configures a client from GOPROXY, GONOPROXY and GOPRIVATE as reported by `go env`
+func NewProxyClient() (*ProxyClient, error) {
+	out, err := goOutput(Root{}, "env", "-json", "GOPROXY", "GONOPROXY", "GOPRIVATE")
+	if err != nil {
+		return nil, err
+	}
+
+	var env struct {
+		GOPROXY   string
+		GONOPROXY string
+		GOPRIVATE string
+	}
+	if err := json.Unmarshal(out, &env); err != nil {
+		return nil, err
+	}
+
+	noProxy := env.GONOPROXY
+	if noProxy == "" {
+		noProxy = env.GOPRIVATE
+	}
+
+	return newProxyClient(env.GOPROXY, noProxy), nil
+}
+
+func newProxyClient(goproxy, noProxy string) *ProxyClient {
+	if goproxy == "" {
+		goproxy = "https://proxy.golang.org,direct"
+	}
+
+	var proxies []proxyEntry
+	for goproxy != "" {
+		var entry proxyEntry
+		if i := strings.IndexAny(goproxy, ",|"); i >= 0 {
+			entry = proxyEntry{url: goproxy[:i], fallbackOnError: goproxy[i] == '|'}
+			goproxy = goproxy[i+1:]
+		} else {
+			entry = proxyEntry{url: goproxy}
+			goproxy = ""
+		}
+		entry.url = strings.TrimSuffix(strings.TrimSpace(entry.url), "/")
+		if entry.url != "" {
+			proxies = append(proxies, entry)
+		}
+	}
+
+	return &ProxyClient{
+		proxies: proxies,
+		noProxy: noProxy,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Versions returns tagged versions of a module sorted in ascending semver order
+func (c *ProxyClient) Versions(path string) ([]string, error) {
+	data, err := c.fetch(path, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, line := range strings.Split(string(data), "\n") {
+		v := strings.TrimSpace(line)
+		if modsemver.IsValid(v) {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return modsemver.Compare(versions[i], versions[j]) < 0
+	})
+
+	return versions, nil
+}
+
+// Latest returns what the proxy considers the latest version, including pseudo-versions
+// of modules without tags
+func (c *ProxyClient) Latest(path string) (VersionInfo, error) {
+	return c.fetchInfo(path, "@latest")
+}
+
+// Info returns metadata of a module version
+func (c *ProxyClient) Info(path, version string) (VersionInfo, error) {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return VersionInfo{}, err
+	}
+	return c.fetchInfo(path, "@v/"+escaped+".info")
+}
+
+// GoMod returns the go.mod file of a module version
+func (c *ProxyClient) GoMod(path, version string) ([]byte, error) {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	return c.fetch(path, "@v/"+escaped+".mod")
+}
+
+// GetModuleInfo is the proxy counterpart of the GetModuleInfo function. Modules the proxies
+// do not serve (GONOPROXY, `direct`) are resolved with the go command.
+func (c *ProxyClient) GetModuleInfo(req Requirement) (Module, error) {
+	mod := newModule(req)
+	current, err := semver.NewVersion(stripV(req.Version))
+	if err != nil {
+		return mod, nil
+	}
+	mod.Current = current
+
+	q, err := c.latestUpgrade(req.Path, req.Version)
+	if errors.Is(err, errNoProxy) {
+		return GetModuleInfo(req)
+	}
+	mod.Retracted = q.Retracted
+	mod.Deprecated = q.Deprecated
+	if err != nil || q.Latest.Version == "" {
+		return mod, err
+	}
+
+	latest := q.Latest
+	toV, err := semver.NewVersion(stripV(latest.Version))
+	if err != nil {
+		return mod, nil
+	}
+
+	mod.Latest = toV
+	mod.LatestTime = latest.Time
+	mod.UpdateCategory = categorize(current, toV)
+	mod.Updatable = true
+
+	return mod, nil
+}
+
+// FindMajorUpgrades is the proxy counterpart of the FindMajorUpgrades function
+func (c *ProxyClient) FindMajorUpgrades(root Root, mods []Module) map[string]Module {
+	return findMajorUpgrades(mods, func(paths []string) map[string]latestVersion {
+		latest := make(map[string]latestVersion, len(paths))
+		var direct []string
+		for _, p := range paths {
+			q, err := c.latestUpgrade(p, "")
+			if errors.Is(err, errNoProxy) {
+				direct = append(direct, p)
+				continue
+			}
+			if err != nil || q.Latest.Version == "" {
+				continue
+			}
+			if v, err := semver.NewVersion(stripV(q.Latest.Version)); err == nil {
+				latest[p] = latestVersion{version: v, time: q.Latest.Time}
+			}
+		}
+		if len(direct) > 0 {
+			for p, v := range queryLatest(root, direct) {
+				latest[p] = v
+			}
+		}
+		return latest
+	})
+}
+
+// ListVersions is the proxy counterpart of the ListVersions function
+func (c *ProxyClient) ListVersions(m Module) ([]*semver.Version, error) {
+	path := m.UpgradePath()
+	versions, err := c.Versions(path)
+	if errors.Is(err, errNoProxy) {
+		return ListVersions(m)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	if f := c.goModFile(path, versions[len(versions)-1]); f != nil {
+		kept := versions[:0]
+		for _, v := range versions {
+			if _, ok := retraction(f.Retract, v); !ok {
+				kept = append(kept, v)
+			}
+		}
+		versions = kept
+	}
+
+	return newerVersions(m, versions), nil
+}
+
+// ReleaseTime is the proxy counterpart of the ReleaseTime function
+func (c *ProxyClient) ReleaseTime(m Module, v *semver.Version) (time.Time, error) {
+	info, err := c.Info(m.UpgradePath(), "v"+v.String())
+	if errors.Is(err, errNoProxy) {
+		return ReleaseTime(m, v)
+	}
+	return info.Time, err
+}
+
+// upgradeQuery is the result of ProxyClient.latestUpgrade
+type upgradeQuery struct {
+	Latest     VersionInfo // empty Version when there is nothing newer than current
+	Retracted  []string    // rationale when current is retracted
+	Deprecated string      // deprecation notice of the latest go.mod
+}
+
+// latestUpgrade mirrors the `upgrade` version query of the go command: the highest release
+// above current, a newer prerelease only if no release exists, and `@latest` for untagged modules.
+// Retracted versions are never proposed.
+func (c *ProxyClient) latestUpgrade(path, current string) (upgradeQuery, error) {
+	var q upgradeQuery
+	versions, err := c.Versions(path)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return q, err
+	}
+
+	// retractions and deprecation come from the go.mod of the latest version
+	var retracts []*modfile.Retract
+	if len(versions) > 0 {
+		if f := c.goModFile(path, versions[len(versions)-1]); f != nil {
+			retracts = f.Retract
+			if f.Module != nil {
+				q.Deprecated = f.Module.Deprecated
+			}
+		}
+	}
+	if why, ok := retraction(retracts, current); ok && current != "" {
+		q.Retracted = []string{why}
+	}
+
+	incompatible := strings.HasSuffix(current, "+incompatible")
+	var release, prerelease string
+	for _, v := range versions {
+		if strings.HasSuffix(v, "+incompatible") && !incompatible {
+			continue
+		}
+		if _, ok := retraction(retracts, v); ok {
+			continue
+		}
+		if modsemver.Prerelease(v) == "" {
+			release = v
+		} else {
+			prerelease = v
+		}
+	}
+
+	best := release
+	if best == "" {
+		best = prerelease
+	}
+	if best == "" && len(versions) == 0 {
+		info, err := c.Latest(path)
+		if err != nil {
+			return q, err
+		}
+		best = info.Version
+	}
+	if best == "" || (current != "" && modsemver.Compare(best, current) <= 0) {
+		return q, nil
+	}
+
+	q.Latest, err = c.Info(path, best)
+	return q, err
+}
+
+// goModFile returns the parsed go.mod published with a module version, nil if unavailable
+func (c *ProxyClient) goModFile(path, version string) *modfile.File {
+	data, err := c.GoMod(path, version)
+	if err != nil {
+		return nil
+	}
+	f, err := modfile.ParseLax(path+"@"+version+"/go.mod", data, nil)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+// retraction reports whether version falls into one of retracts and returns the rationale
+func retraction(retracts []*modfile.Retract, version string) (string, bool) {
+	for _, r := range retracts {
+		if modsemver.Compare(r.Low, version) <= 0 && modsemver.Compare(version, r.High) <= 0 {
+			if r.Rationale == "" {
+				return "retracted by module author", true
+			}
+			return r.Rationale, true
+		}
+	}
+	return "", false
+}
+
+func (c *ProxyClient) fetchInfo(path, suffix string) (VersionInfo, error) {
+	data, err := c.fetch(path, suffix)
+	if err != nil {
+		return VersionInfo{}, err
+	}
+
+	var info VersionInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return VersionInfo{}, fmt.Errorf("%s/%s: %w", path, suffix, err)
+	}
+	return info, nil
+}
+
+// fetch walks the GOPROXY list for a module endpoint such as `@v/list`
+func (c *ProxyClient) fetch(path, suffix string) ([]byte, error) {
+	if module.MatchPrefixPatterns(c.noProxy, path) {
+		return nil, errNoProxy
+	}
+
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, p := range c.proxies {
+		switch p.url {
+		case "off":
+			return nil, fmt.Errorf("%s: module lookup disabled by GOPROXY=off", path)
+		case "direct":
+			// like the go command, modules earlier proxies do not serve are fetched from their origin
+			return nil, errNoProxy
+		}
+
+		data, err := c.get(p.url, escaped+"/"+suffix)
+		if err == nil {
+			return data, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", path, err)
+		if !p.fallbackOnError && !errors.Is(err, errNotFound) {
+			return nil, lastErr
+		}
+	}
+
+	if lastErr == nil {
+		return nil, errNoProxy
+	}
+	return nil, lastErr
+}
+
+func (c *ProxyClient) get(base, rel string) ([]byte, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "file" {
+		dir := filepath.FromSlash(u.Path)
+		if len(dir) > 2 && dir[0] == filepath.Separator && dir[2] == ':' {
+			dir = dir[1:] // file:///C:/proxy on windows
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errNotFound
+		}
+		return data, err
+	}
+
+	resp, err := c.http.Get(base + "/" + rel)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, errNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s/%s: %s", base, rel, resp.Status)
+	}
+
+	return data, nil
+}
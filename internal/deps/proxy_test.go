@@ -0,0 +1,125 @@
+package deps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// fileProxy returns a file:// GOPROXY entry for dir
+func fileProxy(t *testing.T, dir string) string {
+	t.Helper()
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "file://" + filepath.ToSlash(abs)
+}
+
+// brokenProxy returns a file:// proxy failing every request with an error other than not found
+func brokenProxy(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	// reading a directory fails
+	if err := os.MkdirAll(filepath.Join(dir, "example.com", "lib", "@v", "list"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return fileProxy(t, dir)
+}
+
+func TestProxyVersionsSorted(t *testing.T) {
+	c := newProxyClient(fileProxy(t, "testdata/proxy"), "")
+
+	got, err := c.Versions("example.com/lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"v1.1.0", "v1.2.0", "v1.3.0", "v1.10.0", "v1.11.0-rc.1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Versions() = %v, want %v", got, want)
+	}
+}
+
+func TestProxyListVersionsSkipsRetracted(t *testing.T) {
+	c := newProxyClient(fileProxy(t, "testdata/proxy"), "")
+	m := Module{Path: "example.com/lib", Current: semver.MustParse("1.1.0")}
+
+	versions, err := c.ListVersions(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range versions {
+		got = append(got, "v"+v.String())
+	}
+	want := []string{"v1.11.0-rc.1", "v1.10.0", "v1.2.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListVersions() = %v, want %v", got, want)
+	}
+}
+
+func TestProxyLatestUpgrade(t *testing.T) {
+	c := newProxyClient(fileProxy(t, "testdata/proxy"), "")
+
+	q, err := c.latestUpgrade("example.com/lib", "v1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Latest.Version != "v1.10.0" {
+		t.Errorf("latest = %q, want the newest release v1.10.0", q.Latest.Version)
+	}
+	if want := []string{"breaks the client API"}; !reflect.DeepEqual(q.Retracted, want) {
+		t.Errorf("retracted = %q, want %q", q.Retracted, want)
+	}
+}
+
+func TestProxyFallback(t *testing.T) {
+	served := fileProxy(t, "testdata/proxy")
+	empty := fileProxy(t, t.TempDir())
+	broken := brokenProxy(t)
+
+	tests := []struct {
+		name    string
+		goproxy string
+		noProxy string
+		want    error // nil when the fixture is served
+		errText string
+	}{
+		{name: "comma after not found", goproxy: empty + "," + served},
+		{name: "pipe after not found", goproxy: empty + "|" + served},
+		{name: "pipe after error", goproxy: broken + "|" + served},
+		{name: "comma stops on error", goproxy: broken + "," + served, errText: "is a directory"},
+		{name: "not found everywhere", goproxy: empty, want: errNotFound},
+		{name: "off", goproxy: "off", errText: "GOPROXY=off"},
+		{name: "off after not found", goproxy: empty + ",off", errText: "GOPROXY=off"},
+		{name: "direct", goproxy: "direct", want: errNoProxy},
+		{name: "direct after not found", goproxy: empty + ",direct", want: errNoProxy},
+		{name: "direct after error", goproxy: broken + "|direct", want: errNoProxy},
+		{name: "GONOPROXY", goproxy: served, noProxy: "example.com", want: errNoProxy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newProxyClient(tt.goproxy, tt.noProxy)
+			versions, err := c.Versions("example.com/lib")
+			switch {
+			case tt.want != nil:
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("err = %v, want %v", err, tt.want)
+				}
+			case tt.errText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.errText)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case len(versions) == 0:
+				t.Fatal("no versions served")
+			}
+		})
+	}
+}
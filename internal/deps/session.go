@@ -0,0 +1,285 @@
+package deps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+)
+
+// SessionDir is the directory below the working directory sessions are stored in
+const SessionDir = ".modup"
+
+const (
+	sessionManifest = "manifest.json"
+	// maxSessions bounds stored sessions, older ones are dropped
+	maxSessions = 10
+)
+
+// ErrNoSession means there is no stored session to restore
+var ErrNoSession = errors.New("no modup session to undo")
+
+// Session records module files as they were before an upgrade, so it can be undone
+type Session struct {
+	ID      string         `json:"id"`
+	Created time.Time      `json:"created"`
+	Files   []SessionFile  `json:"files"`
+	Majors  []SessionMajor `json:"majors,omitempty"`
+}
+
+// SessionFile is a file saved by a session as files/<Index> of the session directory
+type SessionFile struct {
+	Path    string `json:"path"` // relative to the working directory unless outside of it
+	Index   int    `json:"index"`
+	Missing bool   `json:"missing,omitempty"` // the file did not exist and is removed on undo
+}
+
+// SessionMajor is a major upgrade of a session, whose import rewrites are reverted on undo
+type SessionMajor struct {
+	Root string `json:"root"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SaveSession stores go.mod, go.sum and vendor/modules.txt of every main module
+// upgraded by ms under SessionDir of base
+func SaveSession(base string, ms []Module) (Session, error) {
+	s := Session{Created: time.Now()}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, m := range ms {
+		dir := relPath(base, m.Root.Dir)
+		if m.TargetPath != "" && m.TargetPath != m.Path {
+			s.Majors = append(s.Majors, SessionMajor{Root: dir, From: m.Path, To: m.TargetPath})
+		}
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		for _, name := range []string{"go.mod", "go.sum", filepath.Join("vendor", "modules.txt")} {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+
+	sessions := filepath.Join(base, SessionDir)
+	id := s.Created.Format("20060102-150405")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(sessions, id)); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		id = s.Created.Format("20060102-150405") + "-" + strconv.Itoa(n)
+	}
+	s.ID = id
+
+	dir := filepath.Join(sessions, id)
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0o755); err != nil {
+		return s, err
+	}
+	for i, path := range paths {
+		f := SessionFile{Path: path, Index: i}
+		data, err := os.ReadFile(absPath(base, path))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// vendoring is optional and go.sum missing without requirements
+			if filepath.Base(path) == "go.mod" {
+				return s, err
+			}
+			f.Missing = true
+		case err != nil:
+			return s, err
+		default:
+			if err := os.WriteFile(filepath.Join(dir, "files", strconv.Itoa(i)), data, 0o644); err != nil {
+				return s, err
+			}
+		}
+		s.Files = append(s.Files, f)
+	}
+
+	manifest, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return s, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, sessionManifest), manifest, 0o644); err != nil {
+		return s, err
+	}
+
+	return s, pruneSessions(base)
+}
+
+// Sessions lists sessions stored under base, newest first
+func Sessions(base string) ([]Session, error) {
+	entries, err := os.ReadDir(filepath.Join(base, SessionDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(base, SessionDir, e.Name(), sessionManifest))
+		if err != nil {
+			// interrupted while saving
+			continue
+		}
+		var s Session
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("session %s: %w", e.Name(), err)
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Created.After(sessions[j].Created) })
+
+	return sessions, nil
+}
+
+// RestoreSession writes back the files saved by the session id, the newest one
+// when id is empty, reverts import rewrites of its major upgrades, re-vendors modules
+// whose vendor/modules.txt it restored and removes it. It returns the session and the
+// files it wrote. A failing go mod vendor is returned as *VerifyError.
+func RestoreSession(base, id string) (Session, []string, error) {
+	sessions, err := Sessions(base)
+	if err != nil {
+		return Session{}, nil, err
+	}
+	if len(sessions) == 0 {
+		return Session{}, nil, ErrNoSession
+	}
+
+	s := sessions[0]
+	if id != "" {
+		found := false
+		for _, candidate := range sessions {
+			if candidate.ID == id {
+				s, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			return Session{}, nil, fmt.Errorf("no session %q", id)
+		}
+	}
+
+	dir := filepath.Join(base, SessionDir, s.ID)
+	var restored, vendored []string
+	for _, f := range s.Files {
+		path := absPath(base, f.Path)
+		if f.Missing {
+			if err := os.Remove(path); err == nil {
+				restored = append(restored, f.Path)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return s, restored, err
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "files", strconv.Itoa(f.Index)))
+		if err != nil {
+			return s, restored, err
+		}
+		if current, err := os.ReadFile(path); err == nil && string(current) == string(data) {
+			continue
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return s, restored, err
+		}
+		restored = append(restored, f.Path)
+		if filepath.Base(f.Path) == "modules.txt" {
+			vendored = append(vendored, filepath.Dir(filepath.Dir(f.Path)))
+		}
+	}
+
+	for _, major := range s.Majors {
+		rootDir := absPath(base, major.Root)
+		// code may import the new path on its own when it was required already
+		if requires(rootDir, major.To) {
+			continue
+		}
+		rw, err := rewriteImports(rootDir, major.To, major.From)
+		if err != nil {
+			return s, restored, fmt.Errorf("restore imports of %s: %w", major.From, err)
+		}
+		for _, file := range rw.Files() {
+			restored = append(restored, filepath.Join(major.Root, file))
+		}
+	}
+
+	// only modules.txt is saved, the sources in vendor/ follow it
+	for _, rootDir := range vendored {
+		if err := RunFinishStep(Root{Dir: absPath(base, rootDir)}, []string{"go", "mod", "vendor"}); err != nil {
+			return s, restored, fmt.Errorf("restore vendor of %s: %w", rootDir, err)
+		}
+		restored = append(restored, filepath.Join(rootDir, "vendor"))
+	}
+
+	return s, restored, os.RemoveAll(dir)
+}
+
+// requires reports whether go.mod in dir requires the module at path
+func requires(dir, path string) bool {
+	gomodPath := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(gomodPath)
+	if err != nil {
+		return false
+	}
+	f, err := modfile.ParseLax(gomodPath, data, nil)
+	if err != nil {
+		return false
+	}
+	for _, r := range f.Require {
+		if r.Mod.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func pruneSessions(base string) error {
+	sessions, err := Sessions(base)
+	if err != nil || len(sessions) <= maxSessions {
+		return err
+	}
+	for _, s := range sessions[maxSessions:] {
+		if err := os.RemoveAll(filepath.Join(base, SessionDir, s.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relPath makes path relative to base unless it lies outside of it
+func relPath(base, path string) string {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(absBase, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+	return rel
+}
+
+func absPath(base, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
@@ -0,0 +1,5 @@
+module example.com/lib
+
+go 1.22
+
+retract v1.3.0 // breaks the client API
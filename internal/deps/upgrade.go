@@ -1,19 +1,255 @@
 package deps
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
-func Upgrade(m Module) error {
-	target := "v" + m.Latest.String()
+// UpgradeOptions control checks run after upgrading a module
+type UpgradeOptions struct {
+	Verify bool     // build every package of the main module after the upgrade
+	Test   bool     // also run its tests, implies Verify
+	Check  []string // command verifying the main module instead of go build and go test, implies Verify
+}
+
+func (o UpgradeOptions) verifies() bool {
+	return o.Verify || o.Test || len(o.Check) > 0
+}
+
+// verify runs the check command of o in root, or Verify without one
+func (o UpgradeOptions) verify(root Root) error {
+	if len(o.Check) == 0 {
+		return Verify(root, o.Test)
+	}
+	return runCheck(root, o.Check)
+}
+
+// VerifyError means a command run in the main module after an upgrade failed, such as
+// go build, go test or go mod tidy
+type VerifyError struct {
+	Cmd    string
+	Output string
+}
+
+func (e *VerifyError) Error() string {
+	return e.Cmd + " failed"
+}
+
+// RollbackError means a step after applying an upgrade failed and go.mod, go.sum and
+// rewritten imports were restored. Err is the failure, a *VerifyError for failed checks.
+type RollbackError struct {
+	Err error
+}
+
+func (e *RollbackError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
+// Upgrade applies the update of m to its target version in its owning module. For major upgrades
+// imports are rewritten to the new module path and the rewritten files are returned.
+// When verification fails, go.mod, go.sum and rewritten imports are restored and a
+// *RollbackError wrapping the *VerifyError is returned.
+func Upgrade(m Module, opts UpgradeOptions) ([]string, error) {
+	rw, err := upgradeChecked(m, opts)
+	return rw.Files(), err
+}
+
+func upgradeChecked(m Module, opts UpgradeOptions) (importRewrite, error) {
+	if !opts.verifies() {
+		return upgrade(m)
+	}
+
+	snap, err := snapshotModFiles(m.Root)
+	if err != nil {
+		return importRewrite{}, err
+	}
+	rw, err := upgrade(m)
+	if err != nil {
+		return importRewrite{}, err
+	}
+
+	verr := opts.verify(m.Root)
+	if verr == nil {
+		return rw, nil
+	}
+	if err := snap.restore(); err != nil {
+		return rw, fmt.Errorf("%w\nrestore go.mod: %v", verr, err)
+	}
+	if err := rw.restore(); err != nil {
+		return rw, fmt.Errorf("%w\nrestore imports of %s: %v", verr, m.Path, err)
+	}
+
+	return importRewrite{}, &RollbackError{Err: verr}
+}
+
+// UpgradeCombined upgrades ms, modules of root, with a single go get so the module
+// graph is resolved once for all of them. Imports of major upgrades are rewritten and
+// their requirements swapped first. Rewritten files are returned by new module path.
+// When go get or verification fails, go.mod, go.sum and imports are restored.
+func UpgradeCombined(root Root, ms []Module, opts UpgradeOptions) (map[string][]string, error) {
+	snap, err := snapshotModFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var rws []importRewrite
+	rewrites := make(map[string][]string)
+	undo := func(cause error) (map[string][]string, error) {
+		if err := snap.restore(); err != nil {
+			return nil, fmt.Errorf("%w\nrestore go.mod: %v", cause, err)
+		}
+		if err := restoreRewrites(rws); err != nil {
+			return nil, fmt.Errorf("%w\nrestore imports: %v", cause, err)
+		}
+		return nil, cause
+	}
+
+	gomodPath := filepath.Join(root.Dir, "go.mod")
+	queries := make([]string, 0, len(ms))
+	for _, m := range ms {
+		version := "v" + m.TargetVersion().String()
+		queries = append(queries, m.UpgradePath()+"@"+version)
+		if m.TargetPath == "" || m.TargetPath == m.Path {
+			continue
+		}
+
+		rw, err := rewriteImports(root.Dir, m.Path, m.TargetPath)
+		if err != nil {
+			return undo(fmt.Errorf("rewrite imports of %s: %w", m.Path, err))
+		}
+		rws = append(rws, rw)
+		rewrites[m.TargetPath] = rw.Files()
+		gomod, err := os.ReadFile(gomodPath)
+		if err != nil {
+			return undo(err)
+		}
+		if err := swapRequire(gomodPath, gomod, m.Path, m.TargetPath, version); err != nil {
+			return undo(err)
+		}
+	}
+
+	if err := goGet(root, queries...); err != nil {
+		return undo(err)
+	}
+	if opts.verifies() {
+		if err := opts.verify(root); err != nil {
+			return undo(err)
+		}
+	}
+
+	for path, files := range rewrites {
+		if len(files) == 0 {
+			delete(rewrites, path)
+		}
+	}
+	return rewrites, nil
+}
+
+func upgrade(m Module) (importRewrite, error) {
+	if m.TargetPath != "" && m.TargetPath != m.Path {
+		return upgradeMajor(m)
+	}
+
+	return importRewrite{}, goGet(m.Root, fmt.Sprintf("%s@v%s", m.Path, m.TargetVersion().String()))
+}
+
+// Verify builds every package of root and, with test, runs their tests
+func Verify(root Root, test bool) error {
+	steps := [][]string{{"build", "./..."}}
+	if test {
+		steps = append(steps, []string{"test", "./..."})
+	}
+
+	for _, args := range steps {
+		if err := runCheck(root, append([]string{"go"}, args...)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runCheck runs the command args in root, failures are returned as *VerifyError.
+// The vendor directory is stale until FinishSteps ran, so vendored modules are
+// checked against the module cache.
+func runCheck(root Root, args []string) error {
+	env := []string{"GOWORK=off"}
+	if Vendored(root) {
+		env = append(env, "GOFLAGS="+strings.TrimSpace(os.Getenv("GOFLAGS")+" -mod=mod"))
+	}
+	return runCommand(root, args, env...)
+}
 
-	cmd := exec.Command("go", "get", fmt.Sprintf("%s@%s", m.Path, target))
-	cmd.Env = append(os.Environ(), "GOWORK=off")
+// runCommand runs args in root with env added, failures are returned as *VerifyError
+func runCommand(root Root, args []string, env ...string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = root.Dir
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		output := strings.TrimSpace(string(out))
+		if output == "" {
+			output = err.Error()
+		}
+		return &VerifyError{Cmd: strings.Join(args, " "), Output: output}
+	}
+
+	return nil
+}
+
+// modFiles holds go.mod and go.sum of a main module as they were before an upgrade
+type modFiles struct {
+	dir         string
+	gomod       []byte
+	gosum       []byte
+	gosumExists bool
+}
+
+func snapshotModFiles(root Root) (modFiles, error) {
+	s := modFiles{dir: root.Dir}
+
+	var err error
+	if s.gomod, err = os.ReadFile(filepath.Join(root.Dir, "go.mod")); err != nil {
+		return s, err
+	}
+	s.gosum, err = os.ReadFile(filepath.Join(root.Dir, "go.sum"))
+	switch {
+	case err == nil:
+		s.gosumExists = true
+	case !errors.Is(err, fs.ErrNotExist):
+		return s, err
+	}
+
+	return s, nil
+}
+
+func (s modFiles) restore() error {
+	if err := os.WriteFile(filepath.Join(s.dir, "go.mod"), s.gomod, 0o644); err != nil {
+		return err
+	}
+
+	gosumPath := filepath.Join(s.dir, "go.sum")
+	if !s.gosumExists {
+		if err := os.Remove(gosumPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(gosumPath, s.gosum, 0o644)
+}
 
+func goGet(root Root, queries ...string) error {
+	cmd := goCommand(root, append([]string{"get"}, queries...)...)
 	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("go get %s failed: %v\n%s", m.Path, err, string(out))
+		return fmt.Errorf("go get %s failed: %v\n%s", strings.Join(queries, " "), err, string(out))
 	}
 
 	return nil
@@ -0,0 +1,127 @@
+package deps
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+// Usage lists packages and files of a main module importing packages of a dependency
+type Usage struct {
+	Packages []string // import paths of importing packages
+	Files    []string // importing files relative to the main module directory
+}
+
+// ModuleUsage loads every package of root, tests included, and attributes their imports
+// to the modules required by root. The result is keyed by module path; required modules
+// nothing imports map to an empty Usage.
+func ModuleUsage(root Root) (map[string]*Usage, error) {
+	gomodPath := filepath.Join(root.Dir, "go.mod")
+	data, err := os.ReadFile(gomodPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.Parse(gomodPath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]*Usage, len(f.Require))
+	for _, req := range f.Require {
+		usage[req.Mod.Path] = &Usage{}
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles,
+		Dir:   root.Dir,
+		Env:   append(os.Environ(), "GOWORK=off"),
+		Tests: true,
+	}, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	seenPkg := make(map[string]map[string]bool) // module -> importing packages
+	seenFile := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, pkg := range pkgs {
+		// test variants share files with the package itself
+		for _, file := range pkg.GoFiles {
+			if seenFile[file] {
+				continue
+			}
+			seenFile[file] = true
+
+			imports, err := fileImports(fset, file)
+			if err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(root.Dir, file)
+			if err != nil {
+				rel = file
+			}
+
+			used := make(map[string]bool)
+			for _, imp := range imports {
+				if mod := owningModule(usage, imp); mod != "" && !used[mod] {
+					used[mod] = true
+					usage[mod].Files = append(usage[mod].Files, filepath.ToSlash(rel))
+					if seenPkg[mod] == nil {
+						seenPkg[mod] = make(map[string]bool)
+					}
+					seenPkg[mod][strings.TrimSuffix(pkg.PkgPath, "_test")] = true
+				}
+			}
+		}
+	}
+
+	for mod, importers := range seenPkg {
+		for p := range importers {
+			usage[mod].Packages = append(usage[mod].Packages, p)
+		}
+		sort.Strings(usage[mod].Packages)
+		sort.Strings(usage[mod].Files)
+	}
+
+	return usage, nil
+}
+
+func fileImports(fset *token.FileSet, filename string) ([]string, error) {
+	f, err := parser.ParseFile(fset, filename, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("parse imports: %w", err)
+	}
+
+	imports := make([]string, 0, len(f.Imports))
+	for _, spec := range f.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+			imports = append(imports, p)
+		}
+	}
+	return imports, nil
+}
+
+// owningModule returns the longest module path of usage providing the package importPath
+func owningModule(usage map[string]*Usage, importPath string) string {
+	owner := ""
+	for p := importPath; ; {
+		if _, ok := usage[p]; ok {
+			owner = p
+			break
+		}
+		i := strings.LastIndexByte(p, '/')
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	return owner
+}
@@ -0,0 +1,69 @@
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ListVersions returns versions m can be upgraded to, newest first. Versions are
+// published under m.UpgradePath and retracted ones are left out.
+func ListVersions(m Module) ([]*semver.Version, error) {
+	path := m.UpgradePath()
+	out, err := goOutput(m.Root, "list", "-m", "-versions", "-json", path+"@latest")
+	if err != nil {
+		return nil, err
+	}
+
+	var l goListModule
+	if err := json.Unmarshal(out, &l); err != nil {
+		return nil, err
+	}
+
+	return newerVersions(m, l.Versions), nil
+}
+
+// ReleaseTime returns when version v of the module m is upgraded to was published
+func ReleaseTime(m Module, v *semver.Version) (time.Time, error) {
+	query := m.UpgradePath() + "@v" + v.String()
+	out, err := goOutput(m.Root, "list", "-m", "-json", query)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var l goListModule
+	if err := json.Unmarshal(out, &l); err != nil {
+		return time.Time{}, err
+	}
+	if l.Time.IsZero() {
+		return time.Time{}, fmt.Errorf("%s: release time unknown", query)
+	}
+
+	return l.Time, nil
+}
+
+// newerVersions parses versions above m.Current in descending order. +incompatible
+// versions are only kept when the current one is.
+func newerVersions(m Module, versions []string) []*semver.Version {
+	incompatible := m.Current != nil && strings.HasSuffix(m.Current.Metadata(), "incompatible")
+
+	var newer []*semver.Version
+	for i := len(versions) - 1; i >= 0; i-- {
+		if strings.HasSuffix(versions[i], "+incompatible") && !incompatible {
+			continue
+		}
+		v, err := semver.NewVersion(stripV(versions[i]))
+		if err != nil {
+			continue
+		}
+		if m.Current != nil && !v.GreaterThan(m.Current) {
+			continue
+		}
+		newer = append(newer, v)
+	}
+
+	return newer
+}
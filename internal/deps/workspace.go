@@ -0,0 +1,256 @@
+package deps
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Root describes a main module whose go.mod requirements are scanned
+type Root struct {
+	Dir  string // directory containing go.mod
+	Path string // module path declared in go.mod
+}
+
+// Requirement is a module path required by the go.mod of Root
+type Requirement struct {
+	Root     Root
+	Path     string
+	Version  string // version required by go.mod
+	Indirect bool
+	IsTool   bool   // module provides a package of a go.mod `tool` directive
+	Held     string // reason the project config excludes the requirement from scanning
+}
+
+func (r Requirement) String() string {
+	return r.Path + "@" + r.Root.Dir
+}
+
+// ScanOptions controls which requirements are scanned
+type ScanOptions struct {
+	// Recursive scans every go.mod below the current directory instead of the current module
+	Recursive bool
+	// Indirect includes requirements marked `// indirect`
+	Indirect bool
+	// Tools selects how modules providing go.mod tools are scanned
+	Tools ToolsFilter
+	// Config marks ignored requirements as held, may be nil
+	Config *Config
+}
+
+// ToolsFilter selects how modules providing go.mod tools are scanned
+type ToolsFilter string
+
+const (
+	ToolsInclude ToolsFilter = "include" // scan tools along with other requirements
+	ToolsExclude ToolsFilter = "exclude" // skip tools
+	ToolsOnly    ToolsFilter = "only"    // scan nothing but tools
+)
+
+// Includes reports whether req is scanned under opts.
+// Tool modules are usually marked indirect and are scanned regardless of opts.Indirect.
+func (o ScanOptions) Includes(req Requirement) bool {
+	switch o.Tools {
+	case ToolsExclude:
+		if req.IsTool {
+			return false
+		}
+	case ToolsOnly:
+		return req.IsTool
+	}
+
+	return !req.Indirect || req.IsTool || o.Indirect
+}
+
+func (o ScanOptions) validate() error {
+	switch o.Tools {
+	case "", ToolsInclude, ToolsExclude, ToolsOnly:
+		return nil
+	default:
+		return fmt.Errorf("unknown tools filter %q, expected %q, %q or %q", o.Tools, ToolsInclude, ToolsExclude, ToolsOnly)
+	}
+}
+
+// ListModuleRoots returns the main modules to scan: every go.mod below the current
+// directory in recursive mode, every module `use`d by the active go.work file,
+// or the module in the current directory otherwise
+func ListModuleRoots(opts ScanOptions) ([]Root, error) {
+	if opts.Recursive {
+		return walkModuleRoots(".")
+	}
+
+	goworkPath, err := getGoWorkPath()
+	if err != nil {
+		return nil, err
+	}
+	if goworkPath != "" {
+		return listWorkspaceRoots(goworkPath)
+	}
+
+	gomodPath, err := getGoModPath()
+	if err != nil {
+		return nil, err
+	}
+	root, err := readRoot(filepath.Dir(gomodPath))
+	if err != nil {
+		return nil, err
+	}
+
+	return []Root{root}, nil
+}
+
+// ListRequirements returns requirements of every root returned by ListModuleRoots.
+// Requirements on one of the roots are left out, they resolve to the sibling directory
+// rather than to a published version.
+func ListRequirements(opts ScanOptions) ([]Requirement, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	roots, err := ListModuleRoots(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		members[root.Path] = true
+	}
+
+	var reqs []Requirement
+	for _, root := range roots {
+		rootReqs, err := ListAllModulePaths(root, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", root.Dir, err)
+		}
+		for _, req := range rootReqs {
+			if !members[req.Path] {
+				reqs = append(reqs, req)
+			}
+		}
+	}
+
+	return reqs, nil
+}
+
+func getGoWorkPath() (string, error) {
+	// not goCommand, which turns the workspace off that is looked up here
+	out, err := exec.Command("go", "env", "GOWORK").Output()
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(string(out))
+	if path == "off" || path == os.DevNull {
+		return "", nil
+	}
+	return path, nil
+}
+
+func listWorkspaceRoots(goworkPath string) ([]Root, error) {
+	data, err := os.ReadFile(goworkPath)
+	if err != nil {
+		return nil, err
+	}
+
+	wf, err := modfile.ParseWork(goworkPath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir := filepath.Dir(goworkPath)
+	roots := make([]Root, 0, len(wf.Use))
+	for _, use := range wf.Use {
+		if use == nil || use.Path == "" {
+			continue
+		}
+		dir := use.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workDir, dir)
+		}
+		root, err := readRoot(dir)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+
+	return roots, nil
+}
+
+// walkModuleRoots finds go.mod files below dir, skipping directories the go command ignores
+func walkModuleRoots(dir string) ([]Root, error) {
+	var roots []Root
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		if ignoredDir(dir, path) {
+			return filepath.SkipDir
+		}
+
+		if _, err := os.Stat(filepath.Join(path, "go.mod")); err != nil {
+			return nil
+		}
+		root, err := readRoot(path)
+		if err != nil {
+			return err
+		}
+		roots = append(roots, root)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no go.mod found in %s", dir)
+	}
+
+	return roots, nil
+}
+
+// ignoredDir reports whether the go command ignores directory path below root:
+// names starting with . or _, testdata and vendor
+func ignoredDir(root, path string) bool {
+	if path == root {
+		return false
+	}
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+		name == "testdata" || name == "vendor"
+}
+
+// outsideModule reports whether directory path below the module root holds no packages
+// of it, because the go command ignores it or it is the root of a nested module
+func outsideModule(root, path string) bool {
+	if ignoredDir(root, path) {
+		return true
+	}
+	if path == root {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(path, "go.mod"))
+	return err == nil
+}
+
+func readRoot(dir string) (Root, error) {
+	gomodPath := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(gomodPath)
+	if err != nil {
+		return Root{}, err
+	}
+
+	return Root{
+		Dir:  dir,
+		Path: modfile.ModulePath(data),
+	}, nil
+}
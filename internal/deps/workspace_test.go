@@ -0,0 +1,48 @@
+package deps
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListRequirementsSkipsWorkspaceMembers(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.work": "go 1.21\n\nuse (\n\t./a\n\t./b\n)\n",
+		"a/go.mod": "module example.com/a\n\ngo 1.21\n\nrequire (\n" +
+			"\texample.com/b v0.0.0-00010101000000-000000000000\n" +
+			"\texample.com/lib v1.1.0\n)\n",
+		"b/go.mod": "module example.com/b\n\ngo 1.21\n\nrequire example.com/lib v1.2.0\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOWORK", filepath.Join(dir, "go.work"))
+	t.Setenv("GOTOOLCHAIN", "local")
+
+	reqs, err := ListRequirements(ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, req := range reqs {
+		got = append(got, req.Root.Path+" -> "+req.Path+"@"+req.Version)
+	}
+	want := []string{"example.com/a -> example.com/lib@v1.1.0", "example.com/b -> example.com/lib@v1.2.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requirements = %q, want %q", got, want)
+	}
+}
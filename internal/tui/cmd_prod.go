@@ -3,28 +3,314 @@
 package tui
 
 import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/chaindead/modup/internal/deps"
 )
 
-func getPkgInfo(pkg string) tea.Cmd {
+var (
+	proxyClient = sync.OnceValues(deps.NewProxyClient)
+	scanCache   = sync.OnceValues(func() (*deps.Cache, error) {
+		return deps.OpenCache(*cacheTTL)
+	})
+)
+
+func getPkgInfo(req deps.Requirement) tea.Cmd {
+	return func() tea.Msg {
+		if msg, ok := cachedPkgInfo(req); ok {
+			return applyPolicy(msg)
+		}
+
+		var msg getPackageInfoMsg
+		if *backend == backendProxy {
+			msg = getProxyPkgInfo(req)
+		} else {
+			msg = getGoPkgInfo(req)
+		}
+		storePkgInfo(msg)
+
+		return applyPolicy(msg)
+	}
+}
+
+func getGoPkgInfo(req deps.Requirement) getPackageInfoMsg {
+	mod, err := deps.GetModuleInfo(req)
+	msg := getPackageInfoMsg{req: req, mod: mod, err: err}
+	if major, ok := deps.FindMajorUpgrade(mod); ok {
+		msg.major = &major
+	}
+	return msg
+}
+
+func getProxyPkgInfo(req deps.Requirement) getPackageInfoMsg {
+	client, err := proxyClient()
+	if err != nil {
+		return getPackageInfoMsg{req: req, mod: deps.Module{Path: req.Path, Root: req.Root}, err: err}
+	}
+
+	mod, err := client.GetModuleInfo(req)
+	msg := getPackageInfoMsg{req: req, mod: mod, err: err}
+	if major, ok := client.FindMajorUpgrades(req.Root, []deps.Module{mod})[mod.Path]; ok {
+		msg.major = &major
+	}
+	return msg
+}
+
+func scanBatch(root deps.Root, reqs []deps.Requirement) tea.Cmd {
+	return func() tea.Msg {
+		infos := make([]getPackageInfoMsg, 0, len(reqs))
+		var pending []deps.Requirement
+		for _, req := range reqs {
+			if info, ok := cachedPkgInfo(req); ok {
+				info.batched = true
+				infos = append(infos, applyPolicy(info))
+				continue
+			}
+			pending = append(pending, req)
+		}
+		if len(pending) == 0 {
+			return batchScanMsg{root: root, infos: infos}
+		}
+
+		mods, err := deps.GetModulesInfo(root, pending)
+		if err != nil {
+			return batchScanMsg{root: root, reqs: pending, infos: infos, err: err}
+		}
+
+		majors := deps.FindMajorUpgrades(root, mods)
+		for i, mod := range mods {
+			info := getPackageInfoMsg{req: pending[i], mod: mod, batched: true}
+			if major, ok := majors[mod.Path]; ok {
+				info.major = &major
+			}
+			storePkgInfo(info)
+			infos = append(infos, applyPolicy(info))
+		}
+
+		return batchScanMsg{root: root, infos: infos}
+	}
+}
+
+// cachedPkgInfo returns the scan result of req stored by a previous run
+func cachedPkgInfo(req deps.Requirement) (getPackageInfoMsg, bool) {
+	if *cacheTTL <= 0 || *refresh {
+		return getPackageInfoMsg{}, false
+	}
+	cache, err := scanCache()
+	if err != nil {
+		return getPackageInfoMsg{}, false
+	}
+
+	mod, major, ok := cache.Get(req)
+	if !ok {
+		return getPackageInfoMsg{}, false
+	}
+	return getPackageInfoMsg{req: req, mod: mod, major: major, cached: true}, true
+}
+
+// storePkgInfo caches a successful scan result, the cache is best effort
+func storePkgInfo(msg getPackageInfoMsg) {
+	if msg.err != nil || *cacheTTL <= 0 {
+		return
+	}
+	cache, err := scanCache()
+	if err != nil {
+		return
+	}
+	_ = cache.Put(msg.req, msg.mod, msg.major)
+}
+
+// versionSource looks up versions with the selected backend
+type versionSource struct{}
+
+func (versionSource) Versions(mod deps.Module) ([]*semver.Version, error) {
+	if *backend == backendProxy {
+		client, err := proxyClient()
+		if err != nil {
+			return nil, err
+		}
+		return client.ListVersions(mod)
+	}
+
+	return deps.ListVersions(mod)
+}
+
+func (versionSource) ReleaseTime(mod deps.Module, v *semver.Version) (time.Time, error) {
+	if *backend == backendProxy {
+		client, err := proxyClient()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return client.ReleaseTime(mod, v)
+	}
+
+	return deps.ReleaseTime(mod, v)
+}
+
+func loadReleaseNotes(key string, mod deps.Module) tea.Cmd {
 	return func() tea.Msg {
-		mod, err := deps.GetModuleInfo(pkg)
-		return getPackageInfoMsg{mod, err}
+		notes, err := deps.GetReleaseNotes(mod)
+		return releaseNotesMsg{key: key, notes: notes, err: err}
+	}
+}
+
+// apiDiffSlots bounds concurrent API diffs, each one downloads two module versions
+var apiDiffSlots = sync.OnceValue(func() chan struct{} {
+	return make(chan struct{}, max(*workerCnt, 1))
+})
+
+func diffModuleAPI(key string, mod deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		slots := apiDiffSlots()
+		slots <- struct{}{}
+		defer func() { <-slots }()
+
+		diff, err := deps.DiffAPI(mod)
+		return apiDiffMsg{key: key, diff: diff, err: err}
+	}
+}
+
+func analyzeUsage(root deps.Root) tea.Cmd {
+	return func() tea.Msg {
+		usage, err := deps.ModuleUsage(root)
+		return usageMsg{root: root, usage: usage, err: err}
+	}
+}
+
+func previewUpgrades(mods []deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		previews, err := deps.PreviewUpgrades(mods)
+		return previewMsg{previews: previews, err: err}
+	}
+}
+
+func saveSession(mods []deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		session, err := deps.SaveSession(".", mods)
+		return sessionMsg{session: session, err: err}
+	}
+}
+
+func combinedUpgrade(mods []deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		rewrites, err := deps.UpgradeCombined(mods[0].Root, mods, upgradeOptions())
+		msg := combinedResultMsg{mods: mods, rewrites: rewrites, err: err}
+		if err != nil {
+			msg.failed = "go get"
+			var verr *deps.VerifyError
+			if errors.As(err, &verr) {
+				msg.failed = verr.Cmd
+			}
+		}
+		return msg
+	}
+}
+
+func bisectUpgrade(mods []deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		result, err := deps.UpgradeBisect(mods[0].Root, mods, upgradeOptions())
+		return bisectResultMsg{mods: mods, result: result, err: err}
 	}
 }
 
 func upgradeModule(mod deps.Module) tea.Cmd {
 	return func() tea.Msg {
-		err := deps.Upgrade(mod)
-		return upgradeModuleResultMsg{mod: mod, err: err}
+		if *commit {
+			files, err := commitUpgrade(mod)
+			return upgradeResult(upgradeModuleResultMsg{mod: mod, files: files, err: err, committed: err == nil})
+		}
+		files, err := deps.Upgrade(mod, upgradeOptions())
+		return upgradeResult(upgradeModuleResultMsg{mod: mod, files: files, err: err})
+	}
+}
+
+// branchUpgrade commits the upgrade of mod on a branch of its own off HEAD
+func branchUpgrade(mod deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		msg := upgradeModuleResultMsg{mod: mod, branch: deps.BranchName(mod)}
+		msg.err = deps.OnBranch(mod.Root, msg.branch, func() error {
+			var err error
+			msg.files, err = commitUpgrade(mod)
+			return err
+		})
+		msg.committed = msg.err == nil
+		return upgradeResult(msg)
+	}
+}
+
+// commitUpgrade upgrades, tidies and vendors the root of mod before committing, so every commit stands on its own
+func commitUpgrade(mod deps.Module) ([]string, error) {
+	tmpl, err := commitTemplate()
+	if err != nil {
+		return nil, err
+	}
+	return deps.UpgradeCommit(mod, upgradeOptions(), *tidy, tmpl)
+}
+
+// upgradeResult fills in the check output and whether the upgrade was rolled back from msg.err
+func upgradeResult(msg upgradeModuleResultMsg) upgradeModuleResultMsg {
+	var verr *deps.VerifyError
+	if errors.As(msg.err, &verr) {
+		msg.output = verr.Output
+	}
+	var rerr *deps.RollbackError
+	msg.rolledBack = errors.As(msg.err, &rerr)
+	return msg
+}
+
+// checkModFilesClean refuses to commit upgrades on top of uncommitted go.mod changes
+func checkModFilesClean(reqs []deps.Requirement) error {
+	for _, reqs := range groupByRoot(reqs, requirementRoot) {
+		if err := deps.CheckModFilesClean(reqs[0].Root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishSteps lists go mod tidy with --tidy and go mod vendor for vendored roots
+func finishSteps(root deps.Root) []finishStep {
+	var steps []finishStep
+	for _, args := range deps.FinishSteps(root, *tidy) {
+		steps = append(steps, finishStep{root: root, args: args})
+	}
+	return steps
+}
+
+func runFinishStep(step finishStep) tea.Cmd {
+	return func() tea.Msg {
+		err := deps.RunFinishStep(step.root, step.args)
+		msg := finishStepMsg{step: step, err: err}
+		var verr *deps.VerifyError
+		if errors.As(err, &verr) {
+			msg.output = verr.Output
+		}
+		return msg
 	}
 }
 
 func getPackageList() tea.Cmd {
 	return func() tea.Msg {
-		pkgs, err := deps.ListAllModulePaths()
+		if err := checkFlags(); err != nil {
+			return getPackageListMsg{err: err}
+		}
+
+		cfg, err := projectConfig()
+		if err != nil {
+			return getPackageListMsg{err: err}
+		}
+		opts := scanOptions()
+		opts.Config = cfg
+
+		pkgs, err := deps.ListRequirements(opts)
+		if err == nil && commits() {
+			err = checkModFilesClean(pkgs)
+		}
 		return getPackageListMsg{pkgs, err}
 	}
 }
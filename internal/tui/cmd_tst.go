@@ -74,6 +74,7 @@ var (
 			Current:        mustParseVersion("1.5.0"),
 			Latest:         mustParseVersion("1.5.1"),
 			IsTool:         false,
+			Indirect:       true,
 			UpdateCategory: "patch",
 			Updatable:      true,
 		},
@@ -82,6 +83,7 @@ var (
 			Current:        mustParseVersion("1.8.0"),
 			Latest:         mustParseVersion("1.8.1"),
 			IsTool:         false,
+			Retracted:      []string{"Accidentally removed assert.Eventually"},
 			UpdateCategory: "patch",
 			Updatable:      true,
 		},
@@ -106,6 +108,7 @@ var (
 			Current:        mustParseVersion("1.9.0"),
 			Latest:         mustParseVersion("1.9.1"),
 			IsTool:         false,
+			Deprecated:     "use gorm.io/gorm",
 			UpdateCategory: "patch",
 			Updatable:      true,
 		},
@@ -121,7 +124,8 @@ var (
 			Path:           "github.com/golang-migrate/migrate/v4",
 			Current:        mustParseVersion("4.15.0"),
 			Latest:         mustParseVersion("4.16.0"),
-			IsTool:         false,
+			IsTool:         true,
+			Indirect:       true,
 			UpdateCategory: "minor",
 			Updatable:      true,
 		},
@@ -442,6 +446,7 @@ var (
 			Current:        mustParseVersion("0.17.0"),
 			Latest:         mustParseVersion("0.17.0"),
 			IsTool:         false,
+			Retracted:      []string{"Contains a data race in http2"},
 			UpdateCategory: "same",
 			Updatable:      false,
 		},
@@ -450,12 +455,32 @@ var (
 			Current:        mustParseVersion("0.13.0"),
 			Latest:         mustParseVersion("0.13.0"),
 			IsTool:         false,
+			Deprecated:     "moved to golang.org/x/sys",
 			UpdateCategory: "same",
 			Updatable:      false,
 		},
 	}
 )
 
+var fakeMajors = map[string]deps.Module{
+	"github.com/golang-jwt/jwt/v4": {
+		Path:           "github.com/golang-jwt/jwt/v4",
+		TargetPath:     "github.com/golang-jwt/jwt/v5",
+		Current:        mustParseVersion("4.5.0"),
+		Latest:         mustParseVersion("5.2.1"),
+		UpdateCategory: "major",
+		Updatable:      true,
+	},
+	"github.com/redis/go-redis/v9": {
+		Path:           "github.com/redis/go-redis/v9",
+		TargetPath:     "github.com/redis/go-redis/v10",
+		Current:        mustParseVersion("9.0.0"),
+		Latest:         mustParseVersion("10.0.0"),
+		UpdateCategory: "major",
+		Updatable:      true,
+	},
+}
+
 func mustParseVersion(v string) *semver.Version {
 	ver, err := semver.NewVersion(v)
 	if err != nil {
@@ -464,14 +489,209 @@ func mustParseVersion(v string) *semver.Version {
 	return ver
 }
 
-func getPkgInfo(pkg string) tea.Cmd {
+func getPkgInfo(req deps.Requirement) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(randomTestDelay())
+
+		return applyPolicy(fakePackageInfo(req))
+	}
+}
+
+func fakePackageInfo(req deps.Requirement) getPackageInfoMsg {
+	mod, exists := fakeDeps[req.Path]
+	if !exists {
+		return getPackageInfoMsg{req: req, mod: deps.Module{Path: req.Path, Root: req.Root}}
+	}
+
+	mod.Root = req.Root
+	if mod.Updatable {
+		mod.LatestTime = fakeReleaseTime(mod, mod.Latest)
+	}
+	msg := getPackageInfoMsg{req: req, mod: mod}
+	if major, ok := fakeMajors[req.Path]; ok {
+		major.Root = req.Root
+		major.LatestTime = fakeReleaseTime(major, major.Latest)
+		msg.major = &major
+	}
+	return msg
+}
+
+func scanBatch(root deps.Root, reqs []deps.Requirement) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(randomTestDelay())
+
+		infos := make([]getPackageInfoMsg, 0, len(reqs))
+		for _, req := range reqs {
+			info := fakePackageInfo(req)
+			info.batched = true
+			infos = append(infos, applyPolicy(info))
+		}
+		return batchScanMsg{root: root, reqs: reqs, infos: infos}
+	}
+}
+
+// versionSource makes up a few patch releases of every minor between current and latest,
+// the latest one released two days ago and every older one a week before the next
+type versionSource struct{}
+
+func (versionSource) Versions(mod deps.Module) ([]*semver.Version, error) {
+	latest := mod.Latest
+	var versions []*semver.Version
+	for minor := int64(latest.Minor()); minor >= 0 && len(versions) < 12; minor-- {
+		for patch := int64(2); patch >= 0; patch-- {
+			v := mustParseVersion(fmt.Sprintf("%d.%d.%d", latest.Major(), minor, patch))
+			if v.GreaterThan(latest) || !v.GreaterThan(mod.Current) {
+				continue
+			}
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 || !versions[0].Equal(latest) {
+		versions = append([]*semver.Version{latest}, versions...)
+	}
+	return versions, nil
+}
+
+func (versionSource) ReleaseTime(mod deps.Module, v *semver.Version) (time.Time, error) {
+	return fakeReleaseTime(mod, v), nil
+}
+
+func fakeReleaseTime(mod deps.Module, v *semver.Version) time.Time {
+	latest := mod.Latest
+	steps := int64(latest.Minor()-v.Minor())*3 + int64(latest.Patch()) - int64(v.Patch())
+	if v.Major() != latest.Major() {
+		steps += 100
+	}
+	return time.Now().Add(-time.Duration(2+7*max(steps, 0)) * 24 * time.Hour)
+}
+
+func loadReleaseNotes(key string, mod deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(randomTestDelay() / 2)
+
+		notes := deps.ReleaseNotes{File: "CHANGELOG.md"}
+		versions, _ := versionSource{}.Versions(mod)
+		for _, v := range versions {
+			if v.GreaterThan(mod.TargetVersion()) {
+				continue
+			}
+			notes.Text += fmt.Sprintf("## v%s\n\n- Fixed a bug in %s\n- Improved performance\n\n", v, mod.UpgradePath())
+		}
+		return releaseNotesMsg{key: key, notes: notes}
+	}
+}
+
+// diffModuleAPI reports breaking changes for majors and every fifth module
+func diffModuleAPI(key string, mod deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(randomTestDelay())
+
+		diff := &deps.APIDiff{Added: []string{"pkg.NewOption", "pkg.Client.Close"}}
+		if mod.UpdateCategory == "major" || len(mod.Path)%5 == 0 {
+			diff.Breaking = []string{
+				"pkg.Client.Do changed: method (*Client)(*Request) (*Response) -> method (*Client)(context.Context, *Request) (*Response, error)",
+				"pkg.DefaultTimeout removed",
+			}
+		}
+		return apiDiffMsg{key: key, diff: diff}
+	}
+}
+
+// analyzeUsage makes up importers of every module, a module with a longer path
+// is used by fewer packages and modules with a path of odd length are not imported at all
+func analyzeUsage(root deps.Root) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(randomTestDelay())
+
+		usage := make(map[string]*deps.Usage, len(fakeDeps))
+		for path := range fakeDeps {
+			u := &deps.Usage{}
+			if len(path)%2 == 0 {
+				for i := range max(8-len(path)/6, 1) {
+					pkg := fmt.Sprintf("%s/internal/pkg%d", root.Path, i)
+					u.Packages = append(u.Packages, pkg)
+					u.Files = append(u.Files, fmt.Sprintf("internal/pkg%d/a.go", i), fmt.Sprintf("internal/pkg%d/b.go", i))
+				}
+			}
+			usage[path] = u
+		}
+		return usageMsg{root: root, usage: usage}
+	}
+}
+
+// previewUpgrades bumps the selected modules and a shared transitive dependency
+func previewUpgrades(mods []deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(randomTestDelay())
+
+		var before, after string
+		var changes []deps.VersionChange
+		for _, mod := range mods {
+			from, to := "v"+mod.Current.String(), "v"+mod.TargetVersion().String()
+			before += fmt.Sprintf("\t%s %s\n", mod.Path, from)
+			after += fmt.Sprintf("\t%s %s\n", mod.UpgradePath(), to)
+			if mod.UpgradePath() != mod.Path {
+				changes = append(changes, deps.VersionChange{Path: mod.Path, From: from}, deps.VersionChange{Path: mod.UpgradePath(), To: to})
+				continue
+			}
+			changes = append(changes, deps.VersionChange{Path: mod.Path, From: from, To: to})
+		}
+		before += "\tgolang.org/x/sys v0.20.0 // indirect\n"
+		after += "\tgolang.org/x/sys v0.21.0 // indirect\n"
+		changes = append(changes, deps.VersionChange{Path: "golang.org/x/sys", From: "v0.20.0", To: "v0.21.0"})
+
+		preview := deps.Preview{
+			Root:    mods[0].Root,
+			Diff:    deps.UnifiedDiff("go.mod", "require (\n"+before+")\n", "require (\n"+after+")\n"),
+			Changes: changes,
+		}
+		return previewMsg{previews: []deps.Preview{preview}}
+	}
+}
+
+// saveSession pretends to save a session without writing .modup
+func saveSession([]deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		now := time.Now()
+		return sessionMsg{session: deps.Session{ID: now.Format("20060102-150405"), Created: now}}
+	}
+}
+
+// combinedUpgrade fails for selections with majors, as if they conflicted
+func combinedUpgrade(mods []deps.Module) tea.Cmd {
 	return func() tea.Msg {
 		time.Sleep(randomTestDelay())
 
-		if mod, exists := fakeDeps[pkg]; exists {
-			return getPackageInfoMsg{mod, nil}
+		for _, mod := range mods {
+			if mod.TargetPath != "" {
+				err := fmt.Errorf("go get failed: exit status 1\ngo: %s@v%s requires go >= 1.25 (running go 1.24)", mod.TargetPath, mod.TargetVersion())
+				return combinedResultMsg{mods: mods, err: err, failed: "go get"}
+			}
 		}
-		return getPackageInfoMsg{deps.Module{Path: pkg}, nil}
+		return combinedResultMsg{mods: mods}
+	}
+}
+
+// bisectUpgrade finds majors breaking the build
+func bisectUpgrade(mods []deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(randomTestDelay())
+
+		result := deps.BisectResult{Rewrites: make(map[string][]string), Checks: 1}
+		for _, mod := range mods {
+			if mod.TargetPath == "" {
+				result.Upgraded = append(result.Upgraded, mod)
+				continue
+			}
+			result.Breaking = append(result.Breaking, deps.Breaking{
+				Module: mod,
+				Output: "# example.com/fake/internal/app\ninternal/app/app.go:12:9: undefined: client.Do",
+			})
+		}
+		if len(result.Breaking) > 0 {
+			result.Checks += 2 * len(result.Breaking)
+		}
+		return bisectResultMsg{mods: mods, result: result}
 	}
 }
 
@@ -483,7 +703,57 @@ func upgradeModule(mod deps.Module) tea.Cmd {
 		if r.Float64() < 0.10 {
 			return upgradeModuleResultMsg{mod: mod, err: fmt.Errorf("simulated upgrade error")}
 		}
-		return upgradeModuleResultMsg{mod: mod, err: nil}
+		// majors break the build
+		if opts := upgradeOptions(); opts.Verify && mod.TargetPath != "" {
+			err := &deps.VerifyError{
+				Cmd:    "go build ./...",
+				Output: "# example.com/fake/internal/app\ninternal/app/app.go:12:9: undefined: client.Do",
+			}
+			return upgradeModuleResultMsg{mod: mod, err: &deps.RollbackError{Err: err}, output: err.Output, rolledBack: true}
+		}
+		var files []string
+		if mod.TargetPath != "" {
+			files = []string{"main.go", "internal/app/app.go"}
+		}
+		return upgradeModuleResultMsg{mod: mod, files: files, err: nil, committed: *commit}
+	}
+}
+
+// finishSteps pretends the fake module vendors its dependencies
+func finishSteps(root deps.Root) []finishStep {
+	var steps []finishStep
+	if *tidy {
+		steps = append(steps, finishStep{root: root, args: []string{"go", "mod", "tidy"}})
+	}
+	return append(steps, finishStep{root: root, args: []string{"go", "mod", "vendor"}})
+}
+
+// runFinishStep fails go mod tidy every fourth run on average, as if an import could not be resolved
+func runFinishStep(step finishStep) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(randomTestDelay())
+
+		if step.String() == "go mod tidy" && rand.Float64() < 0.25 {
+			err := &deps.VerifyError{
+				Cmd:    step.String(),
+				Output: "go: finding module for package example.com/fake/client\ngo: example.com/fake/internal/app imports\n\texample.com/fake/client: no matching versions for query \"latest\"",
+			}
+			return finishStepMsg{step: step, err: err, output: err.Output}
+		}
+		return finishStepMsg{step: step}
+	}
+}
+
+// branchUpgrade upgrades mod like upgradeModule, pretending to commit it on a branch
+func branchUpgrade(mod deps.Module) tea.Cmd {
+	upgrade := upgradeModule(mod)
+	return func() tea.Msg {
+		msg := upgrade().(upgradeModuleResultMsg)
+		msg.committed = msg.err == nil
+		if msg.committed {
+			msg.branch = deps.BranchName(mod)
+		}
+		return msg
 	}
 }
 
@@ -491,9 +761,25 @@ func getPackageList() tea.Cmd {
 	time.Sleep(randomTestDelay())
 
 	return func() tea.Msg {
-		packages := make([]string, 0, len(fakeDeps))
-		for pkg := range fakeDeps {
-			packages = append(packages, pkg)
+		if err := checkFlags(); err != nil {
+			return getPackageListMsg{err: err}
+		}
+
+		root := deps.Root{Dir: ".", Path: "example.com/fake"}
+		packages := make([]deps.Requirement, 0, len(fakeDeps))
+		cfg, err := projectConfig()
+		if err != nil {
+			return getPackageListMsg{err: err}
+		}
+		opts := scanOptions()
+		for pkg, mod := range fakeDeps {
+			req := deps.Requirement{Root: root, Path: pkg, Indirect: mod.Indirect, IsTool: mod.IsTool}
+			if cfg.Ignored(pkg) {
+				req.Held = "ignored by config"
+			}
+			if opts.Includes(req) {
+				packages = append(packages, req)
+			}
 		}
 		return getPackageListMsg{packages, nil}
 	}
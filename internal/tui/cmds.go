@@ -3,26 +3,121 @@
 package tui
 
 import (
+	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/chaindead/modup/internal/deps"
 )
 
 type getPackageListMsg struct {
-	packages []string
+	packages []deps.Requirement
 	err      error
 }
 
 type getPackageInfoMsg struct {
-	mod deps.Module
-	err error
+	req     deps.Requirement
+	mod     deps.Module
+	major   *deps.Module
+	err     error
+	batched bool // produced by a batch scan rather than a scan worker
+	cached  bool // read from the scan cache
+}
+
+// batchScanMsg carries results of scanning all requirements of root at once
+type batchScanMsg struct {
+	root  deps.Root
+	reqs  []deps.Requirement // requirements left unscanned because of err
+	infos []getPackageInfoMsg
+	err   error
+}
+
+// usageMsg carries packages of root importing each of its requirements, keyed by module path
+type usageMsg struct {
+	root  deps.Root
+	usage map[string]*deps.Usage
+	err   error
+}
+
+func usageSpinnerName(root deps.Root) string {
+	return "usage:" + root.Dir
+}
+
+func batchSpinnerName(root deps.Root) string {
+	return "batch:" + root.Dir
 }
 
 type upgradeModuleResultMsg struct {
-	mod deps.Module
-	err error
+	mod        deps.Module
+	files      []string // files with rewritten imports
+	err        error
+	output     string // build, test or finish step output when one of them failed
+	rolledBack bool   // the upgrade was applied and undone after a later step failed
+	committed  bool   // with --commit, the upgrade was committed
+	branch     string // with --strategy branch, the branch the upgrade was committed on
+}
+
+// bisectResultMsg carries the outcome of upgrading mods, all of one main module, with bisection
+type bisectResultMsg struct {
+	mods   []deps.Module
+	result deps.BisectResult
+	err    error
+}
+
+// combinedResultMsg carries the outcome of upgrading mods, all of one main module, with a single go get
+type combinedResultMsg struct {
+	mods     []deps.Module
+	rewrites map[string][]string // files with rewritten imports by new module path
+	err      error
+	failed   string // with err, the step that failed: "go get" or the check command run after it
+}
+
+// finishStep is a command run in a main module once all its upgrades are applied
+type finishStep struct {
+	root deps.Root
+	args []string
+}
+
+func (s finishStep) String() string {
+	return strings.Join(s.args, " ")
+}
+
+// finishStepMsg carries the outcome of a finish step, output is set when it failed
+type finishStepMsg struct {
+	step   finishStep
+	err    error
+	output string
+}
+
+// versionListMsg carries versions mod can be upgraded to, newest first
+type versionListMsg struct {
+	mod      deps.Module
+	versions []*semver.Version
+	err      error
+}
+
+// releaseNotesMsg carries release notes of the upgrade identified by key
+type releaseNotesMsg struct {
+	key     string
+	notes   deps.ReleaseNotes
+	err     error
+	loading bool // placeholder while the notes are loaded
+}
+
+// apiDiffMsg carries the exported API diff of the upgrade identified by key
+type apiDiffMsg struct {
+	key     string
+	diff    *deps.APIDiff
+	err     error
+	loading bool // placeholder while the diff is computed
+}
+
+// previewMsg carries what upgrading the selected modules would change
+type previewMsg struct {
+	previews []deps.Preview
+	err      error
 }
 
 type changeModeListMsg bool
@@ -41,6 +136,12 @@ func beginUpgradeCmd(selected []deps.Module) tea.Cmd {
 	return func() tea.Msg { return beginUpgradeMsg{modules: selected} }
 }
 
+// sessionMsg reports the snapshot taken before upgrading, the upgrade starts once it is saved
+type sessionMsg struct {
+	session deps.Session
+	err     error
+}
+
 type moduleStartedMsg struct{}
 
 func moduleStartedCmd() tea.Cmd {
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/pflag"
+
+	"github.com/chaindead/modup/internal/deps"
+)
+
+var projectConfig = sync.OnceValues(func() (*deps.Config, error) {
+	c, err := deps.LoadConfig(*config)
+	if errors.Is(err, fs.ErrNotExist) && !pflag.CommandLine.Changed("config") {
+		// the config file is optional unless requested explicitly
+		c, err = &deps.Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if pflag.CommandLine.Changed("min-age") {
+		if err := c.SetMinAge(*minAge); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+})
+
+// applyPolicy holds back scanned upgrades according to the project config.
+// Scan results are cached as they are, so config changes apply on the next run.
+func applyPolicy(msg getPackageInfoMsg) getPackageInfoMsg {
+	cfg, err := projectConfig()
+	if err != nil || msg.err != nil {
+		return msg
+	}
+
+	msg.mod = cfg.Policy(msg.mod.Path).Apply(msg.mod, versionSource{})
+	if msg.major != nil {
+		major := cfg.Policy(msg.major.Path).Apply(*msg.major, versionSource{})
+		msg.major = &major
+	}
+
+	return msg
+}
+
+// allowedVersions drops versions the project config does not allow mod to be upgraded to.
+// Release times are not checked, picking a version younger than the cooldown is deliberate.
+func allowedVersions(mod deps.Module, versions []*semver.Version) []*semver.Version {
+	cfg, err := projectConfig()
+	if err != nil || mod.Current == nil {
+		return versions
+	}
+
+	policy := cfg.Policy(mod.Path)
+	allowed := versions[:0:0]
+	for _, v := range versions {
+		if _, ok := policy.Allows(mod.Current, v); ok {
+			allowed = append(allowed, v)
+		}
+	}
+	return allowed
+}
+
+func listVersions(mod deps.Module) tea.Cmd {
+	return func() tea.Msg {
+		versions, err := versionSource{}.Versions(mod)
+		return versionListMsg{mod: mod, versions: allowedVersions(mod, versions), err: err}
+	}
+}
@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/chaindead/modup/internal/deps"
+)
+
+// detailPane shows details of the module selected in the list above it
+type detailPane struct {
+	open bool
+	key  string // moduleKey of the shown module
+	view viewport.Model
+	// notes and apiDiffs cache results by moduleKey, shared between model copies
+	notes    map[string]releaseNotesMsg
+	apiDiffs map[string]apiDiffMsg
+}
+
+func newDetailPane() detailPane {
+	return detailPane{
+		view:     viewport.New(0, 0),
+		notes:    make(map[string]releaseNotesMsg),
+		apiDiffs: make(map[string]apiDiffMsg),
+	}
+}
+
+// moduleKey identifies the upgrade of a list item, including its target version
+func moduleKey(mod deps.Module) string {
+	return mod.Root.Dir + "\x00" + mod.Path + "\x00" + mod.UpgradePath() + "@" + mod.TargetVersion().String()
+}
+
+var detailStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.NormalBorder()).
+	BorderTop(true).
+	BorderForeground(lipgloss.Color("245"))
+
+// resizeList splits the screen between the list and the detail pane, if open
+func (m *model) resizeList() {
+	h, v := appStyle.GetFrameSize()
+	width, height := m.width-h, m.height-v
+	if m.detail.open {
+		pane := height / 2
+		height -= pane
+		m.detail.view.Width = width
+		m.detail.view.Height = max(pane-detailStyle.GetVerticalFrameSize(), 1)
+	}
+	m.list.SetSize(width, height)
+}
+
+// loadAPIDiff starts computing the API diff of mod unless it is known already
+func (m *model) loadAPIDiff(mod deps.Module) tea.Cmd {
+	key := moduleKey(mod)
+	if _, ok := m.detail.apiDiffs[key]; ok {
+		return nil
+	}
+	m.detail.apiDiffs[key] = apiDiffMsg{key: key, loading: true}
+	return diffModuleAPI(key, mod)
+}
+
+// setAPIDiff stores an API diff and attaches it to list items of the same upgrade
+func (m *model) setAPIDiff(msg apiDiffMsg) tea.Cmd {
+	m.detail.apiDiffs[msg.key] = msg
+	if msg.err != nil {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	for idx, it := range m.list.Items() {
+		item, ok := it.(listModuleItem)
+		if !ok || moduleKey(item.Module) != msg.key {
+			continue
+		}
+		item.Module.Compat = msg.diff
+		cmds = append(cmds, m.list.SetItem(idx, item))
+	}
+	m.items = m.list.Items()
+
+	return tea.Batch(cmds...)
+}
+
+// syncDetail shows the selected module in the detail pane and
+// loads its release notes and API diff unless they are known already
+func (m *model) syncDetail() tea.Cmd {
+	if !m.detail.open {
+		return nil
+	}
+	item, ok := m.list.SelectedItem().(listModuleItem)
+	if !ok {
+		m.detail.key = ""
+		m.detail.view.SetContent("")
+		return nil
+	}
+
+	mod := item.Module
+	key := moduleKey(mod)
+	if key != m.detail.key {
+		m.detail.key = key
+		m.detail.view.GotoTop()
+	}
+
+	var cmds []tea.Cmd
+	if _, ok := m.detail.notes[key]; !ok {
+		m.detail.notes[key] = releaseNotesMsg{key: key, loading: true}
+		cmds = append(cmds, loadReleaseNotes(key, mod))
+	}
+	cmds = append(cmds, m.loadAPIDiff(mod))
+	m.detail.view.SetContent(m.detailContent(mod))
+
+	return tea.Batch(cmds...)
+}
+
+func (m model) detailContent(mod deps.Module) string {
+	width := m.detail.view.Width
+	title := fmt.Sprintf("%s v%s -> v%s", mod.UpgradePath(), mod.Current, mod.TargetVersion())
+
+	var body string
+	notes := m.detail.notes[moduleKey(mod)]
+	switch {
+	case notes.loading:
+		body = dimStyle.Render("Loading release notes…")
+	case notes.err != nil:
+		body = dimStyle.Render("Release notes: " + notes.err.Error())
+	default:
+		title += " " + dimStyle.Render(notes.notes.File)
+		body = notes.notes.Text
+	}
+
+	wrap := lipgloss.NewStyle().Width(width)
+	content := wrap.Render(lipgloss.NewStyle().Bold(true).Render(title)) + "\n\n"
+	if usage := usageContent(mod.Usage); usage != "" {
+		content += wrap.Render(usage) + "\n\n"
+	}
+	return content +
+		wrap.Render(m.apiDiffContent(mod)) + "\n\n" +
+		wrap.Render(strings.TrimRight(body, "\n"))
+}
+
+// usageContent lists packages and files importing the module, if analyzed
+func usageContent(u *deps.Usage) string {
+	switch {
+	case u == nil:
+		return ""
+	case len(u.Packages) == 0:
+		return dimStyle.Render("Not imported by any package")
+	}
+
+	lines := []string{fmt.Sprintf("Imported by %d packages", len(u.Packages))}
+	for _, pkg := range u.Packages {
+		lines = append(lines, "  "+pkg)
+	}
+	lines = append(lines, fmt.Sprintf("in %d files", len(u.Files)))
+	for _, file := range u.Files {
+		lines = append(lines, "  "+dimStyle.Render(file))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) apiDiffContent(mod deps.Module) string {
+	d := m.detail.apiDiffs[moduleKey(mod)]
+	switch {
+	case d.loading:
+		return dimStyle.Render("Comparing exported API…")
+	case d.err != nil:
+		return dimStyle.Render("API diff: " + d.err.Error())
+	case d.diff == nil:
+		return ""
+	case d.diff.Compatible():
+		return fmt.Sprintf("%s exported API is compatible, %d additions", checkMark, len(d.diff.Added))
+	}
+
+	lines := []string{warnStyle.Render(fmt.Sprintf("%d incompatible API changes", len(d.diff.Breaking)))}
+	for _, change := range d.diff.Breaking {
+		lines = append(lines, "  "+change)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) viewDetail() string {
+	return detailStyle.Render(m.detail.view.View())
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/chaindead/modup/internal/deps"
@@ -12,13 +13,25 @@ import (
 type model struct {
 	mode int
 	// scan mode
-	packages modules
-	modules  []deps.Module
-	scanning namedSpinners
+	packages   modules
+	modules    []deps.Module
+	deprecated []deps.Module // including modules without updates
+	scanning   namedSpinners
+	multiRoot  bool
+	usage      map[string]map[string]*deps.Usage // by root dir and module path
+	analyzing  int                               // usage analyses still running
 
 	// choose mode
-	list  list.Model
-	items []list.Item
+	list   list.Model
+	items  []list.Item
+	detail detailPane
+
+	// pick mode
+	picker  list.Model
+	picking deps.Module
+
+	// preview mode
+	preview viewport.Model
 
 	// upgrade mode
 	upgrading         []deps.Module
@@ -26,6 +39,12 @@ type model struct {
 	upgradeFailures   int
 	upgradedSucceeded []deps.Module
 	upgradedFailed    []deps.Module
+	upgradedBreaking  []deps.Breaking // left out by bisection
+	rewrites          []importRewrite
+	finishing         []finishStep    // run after the last upgrade, the first one is running
+	finishFailed      *finishStepMsg  // stops the remaining finish steps
+	commits           int             // upgrades committed with --commit
+	branches          []upgradeBranch // created with --strategy branch
 
 	//common
 	width    int
@@ -39,6 +58,7 @@ func NewModel() model {
 	return model{
 		spinner:  newSpinner(),
 		progress: newProgress(),
+		detail:   newDetailPane(),
 		scanning: nil,
 	}
 }
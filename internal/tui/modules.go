@@ -1,22 +1,141 @@
 package tui
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
+	"text/template"
+	"time"
 
 	"github.com/spf13/pflag"
+
+	"github.com/chaindead/modup/internal/deps"
+)
+
+var (
+	workerCnt = pflag.UintP("parallel", "p", 20, "number of concurrent api calls")
+	batchScan = pflag.Bool("batch", true, "query all modules of a go.mod with a single go list call")
+	recursive = pflag.BoolP("recursive", "r", false, "scan every go.mod found in subdirectories")
+	indirect  = pflag.BoolP("indirect", "i", false, "include indirect requirements")
+	tools     = pflag.String("tools", string(deps.ToolsInclude), "modules providing go.mod tools: include, exclude or only")
+	backend   = pflag.String("backend", backendGo, "version lookup backend: go (go command) or proxy (GOPROXY protocol)")
+	cacheTTL  = pflag.Duration("cache-ttl", time.Hour, "how long scan results are reused, 0 disables the cache")
+	refresh   = pflag.Bool("refresh", false, "ignore cached scan results")
+	config    = pflag.String("config", deps.ConfigFile, "project config with ignore, pin and update rules")
+	minAge    = pflag.String("min-age", "", "only propose versions released at least that long ago, e.g. 7d or 36h")
+	apiDiff   = pflag.Bool("api-diff", false, "compare exported API of current and target versions in the list, downloading both")
+	usage     = pflag.Bool("usage", false, "find packages importing each module with go/packages, loading every package")
+	dryRun    = pflag.Bool("dry-run", false, "show what upgrading the selected modules would change in go.mod and go.sum, without changing them")
+	verify    = pflag.Bool("verify", false, "build the project after each upgrade and roll back upgrades breaking it")
+	testAfter = pflag.Bool("test", false, "also run tests after each upgrade, implies --verify")
+	check     = pflag.String("check", "", "command verifying the project after upgrading instead of go build and go test, implies --verify")
+	strategy  = pflag.String("strategy", strategyEach, "upgrade strategy: each (one go get per module), combined (one go get for all, each on failure), bisect (all at once, bisecting failures of the check) or branch (each module committed on its own git branch)")
+	tidy      = pflag.Bool("tidy", false, "run go mod tidy after upgrading, vendored modules are re-vendored either way")
+	commit    = pflag.Bool("commit", false, "create a git commit after each successful upgrade, requires --strategy each, branch always commits")
+	commitMsg = pflag.String("commit-message", deps.DefaultCommitMessage, "template of commit messages with .Path, .TargetPath, .From, .To and .Category")
+	sortOrder = pflag.String("sort", sortCategory, "order of modules in the list: category or usage (least used first)")
 )
 
-var workerCnt = pflag.UintP("parallel", "p", 20, "number of concurrent api calls")
+const (
+	backendGo    = "go"
+	backendProxy = "proxy"
+
+	sortCategory = "category"
+	sortUsage    = "usage"
+
+	strategyEach     = "each"
+	strategyCombined = "combined"
+	strategyBisect   = "bisect"
+	strategyBranch   = "branch"
+)
+
+// checkFlags validates flags with a fixed set of values
+func checkFlags() error {
+	for _, check := range []func() error{checkBackend, checkSortOrder, checkStrategy, checkCommit} {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkBackend() error {
+	if *backend != backendGo && *backend != backendProxy {
+		return fmt.Errorf("unknown backend %q, expected %q or %q", *backend, backendGo, backendProxy)
+	}
+	return nil
+}
+
+func checkSortOrder() error {
+	if *sortOrder != sortCategory && *sortOrder != sortUsage {
+		return fmt.Errorf("unknown sort order %q, expected %q or %q", *sortOrder, sortCategory, sortUsage)
+	}
+	if *sortOrder == sortUsage && !*usage {
+		return errors.New("sorting by usage requires --usage")
+	}
+	return nil
+}
+
+func scanOptions() deps.ScanOptions {
+	return deps.ScanOptions{
+		Recursive: *recursive,
+		Indirect:  *indirect,
+		Tools:     deps.ToolsFilter(*tools),
+	}
+}
+
+func checkStrategy() error {
+	switch *strategy {
+	case strategyEach, strategyCombined, strategyBisect, strategyBranch:
+		return nil
+	}
+	return fmt.Errorf("unknown strategy %q, expected %q, %q, %q or %q", *strategy, strategyEach, strategyCombined, strategyBisect, strategyBranch)
+}
+
+var commitTemplate = sync.OnceValues(func() (*template.Template, error) {
+	return deps.ParseCommitMessage(*commitMsg)
+})
+
+// commits reports whether upgrades are committed, with --commit or on branches of their own
+func commits() bool {
+	return *commit || *strategy == strategyBranch
+}
+
+// checkCommit validates the commit message template, only upgrades of their own can be committed one by one
+func checkCommit() error {
+	if !commits() {
+		return nil
+	}
+	if *commit && *strategy != strategyEach && *strategy != strategyBranch {
+		return fmt.Errorf("--commit requires strategy %q, not %q", strategyEach, *strategy)
+	}
+	if *dryRun {
+		return errors.New("committing upgrades and --dry-run exclude each other")
+	}
+	_, err := commitTemplate()
+	return err
+}
+
+// upgradeOptions configures verification, bisection always verifies
+func upgradeOptions() deps.UpgradeOptions {
+	return deps.UpgradeOptions{
+		Verify: *verify || *testAfter || *strategy == strategyBisect,
+		Test:   *testAfter,
+		Check:  strings.Fields(*check),
+	}
+}
 
 type modules struct {
 	current int
 	cnt     int
+	cached  int
 	mu      *sync.RWMutex
 
-	queue []string
+	queue []deps.Requirement
 }
 
-func createModules(packages []string) modules {
+func createModules(packages []deps.Requirement) modules {
 	return modules{
 		queue: packages,
 		cnt:   len(packages),
@@ -24,9 +143,9 @@ func createModules(packages []string) modules {
 	}
 }
 
-func (m *modules) next() (last string, ok bool) {
+func (m *modules) next() (last deps.Requirement, ok bool) {
 	if len(m.queue) == 0 {
-		return "", false
+		return deps.Requirement{}, false
 	}
 
 	last = m.queue[len(m.queue)-1]
@@ -42,3 +161,39 @@ func (m modules) isFinished() bool {
 func (m modules) progressFloat() float64 {
 	return float64(m.current) / float64(m.cnt)
 }
+
+// groupByRoot splits items by the directory of their main module, keeping the original order
+func groupByRoot[T any](items []T, root func(T) deps.Root) [][]T {
+	var groups [][]T
+	index := make(map[string]int)
+	for _, item := range items {
+		dir := root(item).Dir
+		i, ok := index[dir]
+		if !ok {
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+
+	return groups
+}
+
+func requirementRoot(req deps.Requirement) deps.Root {
+	return req.Root
+}
+
+func moduleRoot(mod deps.Module) deps.Root {
+	return mod.Root
+}
+
+// multiRoot reports whether requirements come from more than one main module
+func multiRoot(packages []deps.Requirement) bool {
+	for _, p := range packages {
+		if p.Root.Dir != packages[0].Root.Dir {
+			return true
+		}
+	}
+	return false
+}
@@ -3,8 +3,9 @@ package tui
 import "github.com/charmbracelet/bubbles/spinner"
 
 type namedSpinner struct {
-	name string
-	spin spinner.Model
+	name  string
+	label string
+	spin  spinner.Model
 }
 
 type namedSpinners []namedSpinner
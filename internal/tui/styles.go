@@ -11,9 +11,11 @@ import (
 
 var (
 	// icons
-	checkMark = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).SetString("✓")
-	failMark  = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).SetString("x")
-	stepIcon  = lipgloss.NewStyle().Foreground(lipgloss.Color("33")).SetString("➤")
+	checkMark  = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).SetString("✓")
+	failMark   = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).SetString("x")
+	cachedMark = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).SetString("✓")
+	heldMark   = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).SetString("-")
+	stepIcon   = lipgloss.NewStyle().Foreground(lipgloss.Color("33")).SetString("➤")
 
 	// styles
 	appStyle   = lipgloss.NewStyle().Padding(1, 2)
@@ -22,6 +24,9 @@ var (
 			Bold(true)
 	printStyle          = lipgloss.NewStyle().MarginLeft(1)
 	currentPkgNameStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("211"))
+	dimStyle            = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	badgeStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Italic(true)
+	warnStyle           = lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
 )
 
 func newProgress() progress.Model {
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -16,13 +17,34 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width, m.height = msg.Width, msg.Height
 
 		if len(m.list.Items()) != 0 {
+			m.resizeList()
+		}
+		if m.mode == modePick {
 			h, v := appStyle.GetFrameSize()
-			m.list.SetSize(msg.Width-h, msg.Height-v)
+			m.picker.SetSize(msg.Width-h, msg.Height-v)
+		}
+		if m.mode == modePreview {
+			m.resizePreview()
 		}
 	}
 
 	_, listFinished := msg.(beginUpgradeMsg)
 
+	switch msg.(type) {
+	case releaseNotesMsg, apiDiffMsg:
+		// results of the list screen may arrive while a version is picked or previewed
+		if m.mode == modeList || m.mode == modePick || m.mode == modePreview {
+			return m.listUpdate(msg)
+		}
+		return m, nil
+	}
+
+	if m.mode == modePick {
+		return m.pickUpdate(msg)
+	}
+	if m.mode == modePreview {
+		return m.previewUpdate(msg)
+	}
 	if m.mode == modeList && !listFinished {
 		return m.listUpdate(msg)
 	}
@@ -31,7 +53,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc", "q":
-			return m, tea.Quit
+			return m, m.quit()
 		}
 
 	case spinner.TickMsg:
@@ -63,15 +85,83 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				tea.Quit,
 			)
 		}
-		m.packages = createModules(msg.packages)
+		m.multiRoot = multiRoot(msg.packages)
+
+		// requirements ignored by the project config are reported but not scanned
+		var reqs []deps.Requirement
+		var held []string
+		for _, req := range msg.packages {
+			if req.Held != "" {
+				held = append(held, fmt.Sprintf("%s %s %s", heldMark, m.requirementName(req), dimStyle.Render("held: "+req.Held)))
+				continue
+			}
+			reqs = append(reqs, req)
+		}
+		var heldCmd tea.Cmd
+		if len(held) > 0 {
+			heldCmd = textPrint("%s", strings.Join(held, "\n"))
+		}
+
+		if len(reqs) == 0 {
+			return m, tea.Sequence(
+				heldCmd,
+				stepPrint("No requirements to scan"),
+				tea.Quit,
+			)
+		}
+		m.packages = createModules(reqs)
 
 		cmds := []tea.Cmd{
-			stepPrint("Getting info about %d packages", m.packages.cnt),
+			tea.Sequence(heldCmd, stepPrint("Getting info about %d packages", m.packages.cnt)),
+		}
+		if *usage {
+			m.usage = make(map[string]map[string]*deps.Usage)
+			for _, reqs := range groupByRoot(reqs, requirementRoot) {
+				root := reqs[0].Root
+				m.analyzing++
+				m.scanning = append(m.scanning, namedSpinner{
+					name:  usageSpinnerName(root),
+					label: "imports of " + root.Path,
+					spin:  newSpinner(),
+				})
+				cmds = append(cmds, analyzeUsage(root), m.scanning.lastSpinner().Tick)
+			}
+		}
+		if *batchScan && *backend == backendGo {
+			// workers only pick up requirements of roots whose batch scan failed
+			m.packages.queue = nil
+			for _, reqs := range groupByRoot(reqs, requirementRoot) {
+				root := reqs[0].Root
+				m.scanning = append(m.scanning, namedSpinner{
+					name:  batchSpinnerName(root),
+					label: fmt.Sprintf("%s (%d modules)", root.Path, len(reqs)),
+					spin:  newSpinner(),
+				})
+				cmds = append(cmds, scanBatch(root, reqs), m.scanning.lastSpinner().Tick)
+			}
+
+			return m, tea.Batch(cmds...)
 		}
 		for i := uint(0); i < *workerCnt; i++ {
 			cmds = append(cmds, moduleStartedCmd())
 		}
 
+		return m, tea.Batch(cmds...)
+	case batchScanMsg:
+		m.scanning = m.scanning.remove(batchSpinnerName(msg.root))
+
+		cmds := make([]tea.Cmd, 0, len(msg.infos)+1)
+		for _, info := range msg.infos {
+			cmds = append(cmds, func() tea.Msg { return info })
+		}
+		if msg.err != nil {
+			m.packages.queue = append(m.packages.queue, msg.reqs...)
+			cmds = append(cmds, textPrint("%s batch scan of %s failed, scanning modules one by one (%s)", failMark, msg.root.Path, msg.err))
+			for i := uint(0); i < min(*workerCnt, uint(len(msg.reqs))); i++ {
+				cmds = append(cmds, moduleStartedCmd())
+			}
+		}
+
 		return m, tea.Batch(cmds...)
 	case moduleStartedMsg:
 		pkg, ok := m.packages.next()
@@ -80,8 +170,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.scanning = append(m.scanning, namedSpinner{
-			name: pkg,
-			spin: newSpinner(),
+			name:  pkg.String(),
+			label: m.requirementName(pkg),
+			spin:  newSpinner(),
 		})
 		return m, tea.Batch(
 			getPkgInfo(pkg),
@@ -89,63 +180,158 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		)
 	case getPackageInfoMsg:
 		m.packages.current++
-		m.scanning = m.scanning.remove(msg.mod.Path)
-		if msg.mod.Updatable {
+		m.scanning = m.scanning.remove(msg.req.String())
+		// retracted versions are listed even when there is nothing newer to move to
+		if msg.mod.Updatable || msg.mod.Held != "" || len(msg.mod.Retracted) > 0 {
 			m.modules = append(m.modules, msg.mod)
 		}
+		if msg.major != nil {
+			m.modules = append(m.modules, *msg.major)
+		}
 
-		pkg := msg.mod.Path
+		pkg := m.requirementName(msg.req)
 		mark := checkMark
+		if msg.cached {
+			m.packages.cached++
+			pkg += dimStyle.Render(" (cached)")
+			mark = cachedMark
+		}
+		if len(msg.mod.Retracted) > 0 {
+			pkg += warnStyle.Render(" retracted: " + strings.Join(msg.mod.Retracted, "; "))
+		}
+		if msg.mod.Deprecated != "" {
+			m.deprecated = append(m.deprecated, msg.mod)
+			pkg += warnStyle.Render(" deprecated")
+		}
+		if msg.mod.Held != "" {
+			pkg += dimStyle.Render(" held: " + msg.mod.Held)
+		}
+		if msg.major != nil && msg.major.Held != "" {
+			pkg += dimStyle.Render(" " + msg.major.TargetPath + " held: " + msg.major.Held)
+		}
 		if msg.err != nil {
-			pkg = fmt.Sprintf("%s (%s)", msg.mod.Path, msg.err.Error())
+			pkg = fmt.Sprintf("%s (%s)", pkg, msg.err.Error())
 			mark = failMark
 		}
 
 		if !m.packages.isFinished() {
 			progressCmd := m.progress.SetPercent(m.packages.progressFloat())
+			if msg.batched {
+				return m, tea.Batch(progressCmd, textPrint("%s %s", mark, pkg))
+			}
 			return m, tea.Batch(progressCmd, textPrint("%s %s", mark, pkg), moduleStartedCmd())
 		}
 
-		if len(m.modules) == 0 {
-			return m, tea.Sequence(
-				stepPrint("Everything is up-to-date"),
-				tea.Quit,
-			)
-		}
-		m.modules = sortModules(m.modules)
-
 		return m, tea.Sequence(
 			textPrint("%s %s", mark, pkg),
-			changeModeList(),
+			m.finishScan(),
 		)
 
+	case usageMsg:
+		m.analyzing--
+		m.scanning = m.scanning.remove(usageSpinnerName(msg.root))
+
+		var cmd tea.Cmd
+		if msg.err != nil {
+			cmd = textPrint("%s imports of %s (%s)", failMark, msg.root.Path, msg.err)
+		} else {
+			m.usage[msg.root.Dir] = msg.usage
+		}
+		if m.packages.isFinished() {
+			return m, tea.Sequence(cmd, m.finishScan())
+		}
+
+		return m, cmd
+
 	case changeModeListMsg:
 		l, items := m.newList()
 		m.list = l
 		m.items = items
 		m.mode = modeList
 
-		return m, nil
+		var cmds []tea.Cmd
+		if *apiDiff {
+			for _, mod := range m.modules {
+				if mod.Updatable {
+					cmds = append(cmds, m.loadAPIDiff(mod))
+				}
+			}
+		}
+		return m, tea.Batch(cmds...)
 
 	// Begin upgrade flow
 	case beginUpgradeMsg:
+		if *dryRun {
+			m.upgrading = msg.modules
+			m.upgradeIndex = 0
+			m.mode = modeUpgrade
+			return m, tea.Sequence(
+				stepPrint("Previewing %d packages", len(m.upgrading)),
+				tea.Batch(m.spinner.Tick, previewUpgrades(m.upgrading)),
+			)
+		}
+
 		m.upgrading = msg.modules
 		m.upgradeIndex = 0
 		m.upgradeFailures = 0
 		m.upgradedSucceeded = nil
 		m.upgradedFailed = nil
+		m.upgradedBreaking = nil
+		m.rewrites = nil
+		m.finishing = nil
+		m.finishFailed = nil
+		m.commits = 0
+		m.branches = nil
 		m.mode = modeUpgrade
 		m.progress = newProgress()
 
+		return m, tea.Batch(m.spinner.Tick, saveSession(m.upgrading))
+
+	case sessionMsg:
+		if msg.err != nil {
+			return m, m.quit(
+				textPrint("%s save session: %s", failMark, msg.err),
+				stepPrint("Nothing upgraded"),
+			)
+		}
+
 		cmds := []tea.Cmd{
 			stepPrint("Upgrading %d packages", len(m.upgrading)),
-			m.spinner.Tick,
+			textPrint("%s", dimStyle.Render(fmt.Sprintf("session %s saved, `modup undo` restores go.mod and go.sum", msg.session.ID))),
+		}
+		switch *strategy {
+		case strategyBisect:
+			for _, mods := range groupByRoot(m.upgrading, moduleRoot) {
+				cmds = append(cmds, bisectUpgrade(mods))
+			}
+			return m, tea.Sequence(cmds...)
+		case strategyCombined:
+			for _, mods := range groupByRoot(m.upgrading, moduleRoot) {
+				cmds = append(cmds, combinedUpgrade(mods))
+			}
+			return m, tea.Sequence(cmds...)
+		case strategyBranch:
+			for _, mod := range m.upgrading {
+				cmds = append(cmds, branchUpgrade(mod))
+			}
+			return m, tea.Sequence(cmds...)
 		}
 		for _, mod := range m.upgrading {
 			cmds = append(cmds, upgradeModule(mod))
 		}
 		return m, tea.Sequence(cmds...)
 
+	case previewMsg:
+		// dry run
+		m.upgradeIndex = len(m.upgrading)
+		if msg.err != nil {
+			return m, m.quit(textPrint("%s %s", failMark, msg.err))
+		}
+		return m, m.quit(
+			textPrint("%s", previewContent(msg.previews, m.multiRoot)),
+			stepPrint("Dry run, go.mod and go.sum are unchanged"),
+		)
+
 	case upgradeModuleResultMsg:
 		mark := checkMark
 		if msg.err != nil {
@@ -155,36 +341,258 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.upgradedSucceeded = append(m.upgradedSucceeded, msg.mod)
 		}
+		if len(msg.files) > 0 && msg.branch == "" {
+			// imports rewritten on a branch are not in the working tree
+			m.rewrites = append(m.rewrites, importRewrite{mod: msg.mod, files: msg.files})
+		}
+		line := textPrint("%s %s", mark, msg.mod.Path)
+		switch {
+		case msg.branch != "" && msg.committed:
+			m.branches = append(m.branches, upgradeBranch{mod: msg.mod, name: msg.branch})
+			line = textPrint("%s %s %s", mark, msg.mod.Path, dimStyle.Render("committed on "+msg.branch))
+		case msg.committed:
+			m.commits++
+			line = textPrint("%s %s %s", mark, msg.mod.Path, dimStyle.Render("committed"))
+		}
+		if msg.err != nil && (msg.output != "" || msg.rolledBack) {
+			reason, output := firstLine(msg.err.Error()), msg.output
+			if output == "" {
+				// errors of go and git commands carry their output below the first line
+				output = strings.TrimSpace(strings.TrimPrefix(msg.err.Error(), reason))
+			}
+			if msg.rolledBack {
+				reason += ", rolled back"
+			}
+			line = textPrint("%s %s %s", mark, msg.mod.Path, warnStyle.Render(reason))
+			if output != "" {
+				line = textPrint("%s %s %s\n%s", mark, msg.mod.Path, warnStyle.Render(reason), indentOutput(output))
+			}
+		}
 		m.upgradeIndex++
-		progressCmd := m.progress.SetPercent(float64(m.upgradeIndex) / float64(len(m.upgrading)))
-		if m.upgradeIndex >= len(m.upgrading) {
-			doneCmds := []tea.Cmd{
-				progressCmd,
-				textPrint("%s %s", mark, msg.mod.Path),
+
+		return m, m.upgradeProgress(line)
+
+	case bisectResultMsg:
+		return m, m.bisectResult(msg)
+
+	case combinedResultMsg:
+		root := msg.mods[0].Root.Path
+		if msg.err != nil {
+			what := fmt.Sprintf("single go get failed (%s)", firstLine(msg.err.Error()))
+			if msg.failed != "go get" {
+				what = msg.failed + " failed after a single go get"
+			}
+			// runs alongside the combined upgrades still queued for other roots, each has its own go.mod
+			cmds := []tea.Cmd{textPrint("%s %s: %s, upgrading %d modules one by one", failMark, root, what, len(msg.mods))}
+			for _, mod := range msg.mods {
+				cmds = append(cmds, upgradeModule(mod))
 			}
-			doneCmds = append(doneCmds, m.printDone()...)
-			doneCmds = append(doneCmds, tea.Quit)
+			return m, tea.Sequence(cmds...)
+		}
 
-			return m, tea.Sequence(doneCmds...)
+		lines := []tea.Cmd{textPrint("%s %s: %d modules upgraded with a single go get", checkMark, root, len(msg.mods))}
+		for _, mod := range msg.mods {
+			m.upgradedSucceeded = append(m.upgradedSucceeded, mod)
+			if files := msg.rewrites[mod.UpgradePath()]; len(files) > 0 {
+				m.rewrites = append(m.rewrites, importRewrite{mod: mod, files: files})
+			}
+			lines = append(lines, textPrint("%s %s", checkMark, mod.Path))
 		}
-		return m, tea.Batch(
-			progressCmd,
-			textPrint("%s %s", mark, msg.mod.Path),
-		)
+		m.upgradeIndex += len(msg.mods)
+
+		return m, m.upgradeProgress(lines...)
+
+	case finishStepMsg:
+		m.finishing = m.finishing[1:]
+		line := textPrint("%s %s", checkMark, m.finishStepName(msg.step))
+		if msg.err != nil {
+			// a failing step leaves go.mod or vendor inconsistent, later steps would build on it
+			m.finishFailed = &msg
+			m.finishing = nil
+			line = textPrint("%s %s %s\n%s", failMark, m.finishStepName(msg.step), warnStyle.Render(msg.err.Error()), indentOutput(msg.output))
+		}
+		if len(m.finishing) > 0 {
+			return m, tea.Sequence(line, runFinishStep(m.finishing[0]))
+		}
+
+		return m, tea.Sequence(append(append([]tea.Cmd{line}, m.printDone()...), tea.Quit)...)
 	}
 
 	return m, nil
 }
 
+// upgradeProgress prints lines and advances the progress bar, after the last upgrade
+// it runs the finish steps of upgraded main modules or prints the summary and quits
+func (m *model) upgradeProgress(lines ...tea.Cmd) tea.Cmd {
+	progressCmd := m.progress.SetPercent(float64(m.upgradeIndex) / float64(len(m.upgrading)))
+	if m.upgradeIndex < len(m.upgrading) {
+		return tea.Batch(progressCmd, tea.Sequence(lines...))
+	}
+
+	doneCmds := append([]tea.Cmd{progressCmd}, lines...)
+	// committed upgrades were tidied and vendored before they were committed
+	if !commits() {
+		for _, mods := range groupByRoot(m.upgradedSucceeded, moduleRoot) {
+			m.finishing = append(m.finishing, finishSteps(mods[0].Root)...)
+		}
+	}
+	if len(m.finishing) > 0 {
+		doneCmds = append(doneCmds, stepPrint("Finishing"), runFinishStep(m.finishing[0]))
+		return tea.Sequence(doneCmds...)
+	}
+	doneCmds = append(doneCmds, m.printDone()...)
+	doneCmds = append(doneCmds, tea.Quit)
+
+	return tea.Sequence(doneCmds...)
+}
+
+// bisectResult records the outcome of upgrading all selected modules of a main module at once
+func (m *model) bisectResult(msg bisectResultMsg) tea.Cmd {
+	res := msg.result
+	var lines []tea.Cmd
+
+	done := make(map[string]bool)
+	for _, mod := range res.Upgraded {
+		done[moduleKey(mod)] = true
+		m.upgradedSucceeded = append(m.upgradedSucceeded, mod)
+		lines = append(lines, textPrint("%s %s", checkMark, mod.Path))
+		if files := res.Rewrites[mod.UpgradePath()]; len(files) > 0 {
+			m.rewrites = append(m.rewrites, importRewrite{mod: mod, files: files})
+		}
+	}
+	for _, b := range res.Breaking {
+		done[moduleKey(b.Module)] = true
+		m.upgradedBreaking = append(m.upgradedBreaking, b)
+		lines = append(lines, textPrint("%s %s %s", failMark, b.Module.Path, warnStyle.Render("breaks the check, left out")))
+	}
+	for _, f := range res.Failed {
+		done[moduleKey(f.Module)] = true
+		m.upgradeFailures++
+		m.upgradedFailed = append(m.upgradedFailed, f.Module)
+		lines = append(lines, textPrint("%s %s (%s)", failMark, f.Module.Path, firstLine(f.Err.Error())))
+	}
+	if msg.err != nil {
+		for _, mod := range msg.mods {
+			if !done[moduleKey(mod)] {
+				m.upgradeFailures++
+				m.upgradedFailed = append(m.upgradedFailed, mod)
+				lines = append(lines, textPrint("%s %s", failMark, mod.Path))
+			}
+		}
+		lines = append(lines, textPrint("%s %s: %s", failMark, msg.mods[0].Root.Path, msg.err))
+	}
+	lines = append(lines, textPrint("%s", dimStyle.Render(fmt.Sprintf("%d check runs", res.Checks))))
+
+	m.upgradeIndex += len(msg.mods)
+
+	return m.upgradeProgress(lines...)
+}
+
+// quit prints cmds and the deprecated modules found by the scan, then ends the program
+func (m model) quit(cmds ...tea.Cmd) tea.Cmd {
+	cmds = append(cmds, m.printDeprecated()...)
+	return tea.Sequence(append(cmds, tea.Quit)...)
+}
+
+// finishScan shows the list once both the scan and usage analyses are done
+func (m *model) finishScan() tea.Cmd {
+	if m.analyzing > 0 {
+		return nil
+	}
+
+	if !hasUpdatable(m.modules) {
+		return m.quit(stepPrint("Everything is up-to-date"))
+	}
+
+	for i, mod := range m.modules {
+		usage, ok := m.usage[mod.Root.Dir]
+		if !ok {
+			continue
+		}
+		if u, ok := usage[mod.Path]; ok {
+			m.modules[i].Usage = u
+		} else {
+			// tools and requirements of other go.mod files are not imported by root
+			m.modules[i].Usage = &deps.Usage{}
+		}
+	}
+	m.modules = sortModules(m.modules)
+
+	return changeModeList()
+}
+
+// maxOutputLines bounds build and test output printed for a failed verification
+const maxOutputLines = 30
+
+func indentOutput(out string) string {
+	lines := strings.Split(out, "\n")
+	if len(lines) > maxOutputLines {
+		lines = append(lines[:maxOutputLines], fmt.Sprintf("… %d more lines", len(lines)-maxOutputLines))
+	}
+	for i, line := range lines {
+		lines[i] = "    " + dimStyle.Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// finishStepName is a display name of step, qualified by its main module when several are scanned
+func (m model) finishStepName(step finishStep) string {
+	if !m.multiRoot {
+		return step.String()
+	}
+	return fmt.Sprintf("%s (%s)", step, step.root.Path)
+}
+
+// requirementName is a display name of req, qualified by its main module when several are scanned
+func (m model) requirementName(req deps.Requirement) string {
+	if !m.multiRoot {
+		return req.Path
+	}
+	return fmt.Sprintf("%s (%s)", req.Path, req.Root.Path)
+}
+
 var categoryMap = map[string]int{
 	"minor":      1,
 	"patch":      2,
 	"prerelease": 3,
 	"metadata":   4,
+	"major":      5,
+}
+
+// hasUpdatable reports whether any of ms can be upgraded, held modules cannot
+func hasUpdatable(ms []deps.Module) bool {
+	for _, mod := range ms {
+		if mod.Updatable {
+			return true
+		}
+	}
+	return false
 }
 
+// sortModules groups modules by their main module, then puts modules on a retracted
+// version first, held modules last and orders the rest by update category or,
+// with --sort=usage, least used first
 func sortModules(ms []deps.Module) []deps.Module {
-	sort.Slice(ms, func(i, j int) bool {
+	sort.SliceStable(ms, func(i, j int) bool {
+		if ms[i].Root.Dir != ms[j].Root.Dir {
+			return ms[i].Root.Dir < ms[j].Root.Dir
+		}
+		ri, rj := len(ms[i].Retracted) > 0, len(ms[j].Retracted) > 0
+		if ri != rj {
+			return ri
+		}
+		if ms[i].Updatable != ms[j].Updatable {
+			return ms[i].Updatable
+		}
+		if *sortOrder == sortUsage {
+			ui, uj := ms[i].Usage, ms[j].Usage
+			if ui != nil && uj != nil && len(ui.Packages) != len(uj.Packages) {
+				return len(ui.Packages) < len(uj.Packages)
+			}
+			if ui != nil && uj != nil && len(ui.Files) != len(uj.Files) {
+				return len(ui.Files) < len(uj.Files)
+			}
+		}
 		return categoryMap[ms[i].UpdateCategory] < categoryMap[ms[j].UpdateCategory]
 	})
 
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -16,6 +17,7 @@ import (
 type listModuleItem struct {
 	Module   deps.Module
 	Selected bool
+	ShowRoot bool
 }
 
 func (i listModuleItem) Title() string {
@@ -25,16 +27,72 @@ func (i listModuleItem) Title() string {
 		box = "●"
 	}
 
-	cat := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575")).Render(i.Module.UpdateCategory)
+	catColor := lipgloss.Color("#04B575")
+	if i.Module.UpdateCategory == "major" {
+		catColor = lipgloss.Color("#F59E0B")
+	}
+	cat := lipgloss.NewStyle().Foreground(catColor).Render(i.Module.UpdateCategory)
+	if !i.Module.Updatable {
+		box = " "
+		cat = dimStyle.Render("held")
+		if i.Module.Held == "" {
+			cat = dimStyle.Render("no update")
+		}
+	}
 	name := lipgloss.NewStyle().Bold(true).Render(i.Module.Path)
+	if i.ShowRoot {
+		name += " " + dimStyle.Render("("+i.Module.Root.Path+")")
+	}
+	if i.Module.IsTool {
+		name += " " + badgeStyle.Render("tool")
+	}
+	if i.Module.Indirect {
+		name += " " + badgeStyle.Render("indirect")
+	}
+	if len(i.Module.Retracted) > 0 {
+		name += " " + warnStyle.Render("retracted")
+	}
+	if i.Module.Deprecated != "" {
+		name += " " + warnStyle.Render("deprecated")
+	}
+	if i.Module.BreaksClaim() {
+		name += " " + warnStyle.Render("claims "+i.Module.UpdateCategory+" but breaks API")
+	}
 
 	return fmt.Sprintf("%s %s", box, name+" "+cat)
 }
 
 func (i listModuleItem) Description() string {
 	from := lipgloss.NewStyle().Foreground(lipgloss.Color("#6C91C2")).Render("v" + i.Module.Current.String())
-	to := lipgloss.NewStyle().Foreground(lipgloss.Color("#22C55E")).Render("v" + i.Module.Latest.String())
-	return fmt.Sprintf("%s -> %s", from, to)
+	to := lipgloss.NewStyle().Foreground(lipgloss.Color("#22C55E")).Render("v" + i.Module.TargetVersion().String())
+	if i.Module.TargetPath != "" {
+		to = dimStyle.Render(i.Module.TargetPath+"@") + to
+	}
+	if i.Module.Target != nil {
+		to += " " + dimStyle.Render("(latest v"+i.Module.Latest.String()+")")
+	}
+	if t := i.Module.TargetReleaseTime(); !t.IsZero() {
+		to += " " + dimStyle.Render("released "+t.Format(time.DateOnly))
+	}
+	desc := fmt.Sprintf("%s -> %s", from, to)
+	if !i.Module.Updatable && i.Module.Held == "" {
+		desc = from + " " + dimStyle.Render("no newer version")
+	}
+	if len(i.Module.Retracted) > 0 {
+		desc += " " + warnStyle.Render("retracted: "+strings.Join(i.Module.Retracted, "; "))
+	}
+	if i.Module.Deprecated != "" {
+		desc += " " + warnStyle.Render("deprecated: "+i.Module.Deprecated)
+	}
+	if i.Module.Held != "" {
+		desc += " " + dimStyle.Render("held: "+i.Module.Held)
+	}
+	if u := i.Module.Usage; u != nil && len(u.Packages) == 0 {
+		desc += " " + dimStyle.Render("not imported")
+	} else if u != nil {
+		desc += " " + dimStyle.Render(fmt.Sprintf("used by %d packages, %d files", len(u.Packages), len(u.Files)))
+	}
+	return desc
 }
 
 func (i listModuleItem) FilterValue() string {
@@ -50,17 +108,35 @@ func listItemSelected(listItem list.Item) bool {
 	return listItem.(listModuleItem).Selected
 }
 
+// listItemSelectable reports whether the item may be upgraded, held modules and
+// retracted ones without a newer version may not
+func listItemSelectable(listItem list.Item) bool {
+	return listItem.(listModuleItem).Module.Updatable
+}
+
 func listItemSetSelected(listItem list.Item, selected bool) list.Item {
 	item := listItem.(listModuleItem)
-	item.Selected = selected
+	item.Selected = selected && item.Module.Updatable
 
 	return item
 }
 
-func findItemIndexByPath(items []list.Item, modulePath string) int {
+// selectedModules returns modules of the visible selected items
+func selectedModules(m *list.Model) []deps.Module {
+	selected := make([]deps.Module, 0)
+	for _, it := range m.VisibleItems() {
+		if listItemSelected(it) {
+			selected = append(selected, it.(listModuleItem).Module)
+		}
+	}
+	return selected
+}
+
+func findItemIndex(items []list.Item, mod deps.Module) int {
 	for idx, it := range items {
 		if lm, ok := it.(listModuleItem); ok {
-			if lm.Module.Path == modulePath {
+			if lm.Module.Path == mod.Path && lm.Module.Root.Dir == mod.Root.Dir &&
+				lm.Module.TargetPath == mod.TargetPath {
 				return idx
 			}
 		}
@@ -72,6 +148,11 @@ type listKeyMap struct {
 	toggleItem key.Binding
 	toggleAll  key.Binding
 	update     key.Binding
+	pick       key.Binding
+	preview    key.Binding
+	notes      key.Binding
+	scrollDown key.Binding
+	scrollUp   key.Binding
 }
 
 func newListKeyMap() *listKeyMap {
@@ -88,6 +169,26 @@ func newListKeyMap() *listKeyMap {
 			key.WithKeys("enter", "u"),
 			key.WithHelp("enter", "to update selected"),
 		),
+		pick: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "to pick version"),
+		),
+		preview: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "to preview go.mod changes"),
+		),
+		notes: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "to toggle release notes"),
+		),
+		scrollDown: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "to scroll notes down"),
+		),
+		scrollUp: key.NewBinding(
+			key.WithKeys("ctrl+u"),
+			key.WithHelp("ctrl+u", "to scroll notes up"),
+		),
 	}
 }
 
@@ -108,6 +209,13 @@ func newItemDelegate(keys *listKeyMap) list.DefaultDelegate {
 				idx := m.GlobalIndex()
 				if idx >= 0 && idx < len(m.Items()) {
 					item := m.Items()[idx]
+					if !listItemSelectable(item) {
+						mod := item.(listModuleItem).Module
+						if mod.Held == "" {
+							return m.NewStatusMessage(statusMessageStyle(mod.Path + " has no newer version"))
+						}
+						return m.NewStatusMessage(statusMessageStyle(mod.Path + " is held: " + mod.Held))
+					}
 					newItem := listItemSetSelected(item, !listItemSelected(item))
 					setCmd := m.SetItem(idx, newItem)
 
@@ -123,7 +231,7 @@ func newItemDelegate(keys *listKeyMap) list.DefaultDelegate {
 				visible := m.VisibleItems()
 				allVisibleSelected := true
 				for _, it := range visible {
-					if !listItemSelected(it) {
+					if listItemSelectable(it) && !listItemSelected(it) {
 						allVisibleSelected = false
 						break
 					}
@@ -135,7 +243,7 @@ func newItemDelegate(keys *listKeyMap) list.DefaultDelegate {
 					if !ok {
 						continue
 					}
-					underlyingIdx := findItemIndexByPath(m.Items(), lm.Module.Path)
+					underlyingIdx := findItemIndex(m.Items(), lm.Module)
 					if underlyingIdx < 0 {
 						continue
 					}
@@ -152,33 +260,47 @@ func newItemDelegate(keys *listKeyMap) list.DefaultDelegate {
 				return tea.Batch(cmds...)
 
 			case key.Matches(msg, keys.update):
-				selected := make([]deps.Module, 0)
-				for _, it := range m.VisibleItems() {
-					if listItemSelected(it) {
-						lm := it.(listModuleItem)
-						selected = append(selected, lm.Module)
-					}
-				}
-
+				selected := selectedModules(m)
 				if len(selected) == 0 {
 					return m.NewStatusMessage(statusMessageStyle("No packages selected to update"))
 				}
 
 				return beginUpgradeCmd(selected)
+
+			case key.Matches(msg, keys.preview):
+				selected := selectedModules(m)
+				if len(selected) == 0 {
+					return m.NewStatusMessage(statusMessageStyle("No packages selected to preview"))
+				}
+
+				return tea.Batch(
+					m.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Previewing %d packages", len(selected)))),
+					previewUpgrades(selected),
+				)
+
+			case key.Matches(msg, keys.pick):
+				lm, ok := m.SelectedItem().(listModuleItem)
+				if !ok {
+					return nil
+				}
+				return tea.Batch(
+					m.NewStatusMessage(statusMessageStyle("Listing versions of "+lm.Module.UpgradePath())),
+					listVersions(lm.Module),
+				)
 			}
 		}
 
 		return nil
 	}
 
-	help := []key.Binding{keys.toggleItem, keys.toggleAll, keys.update}
+	help := []key.Binding{keys.toggleItem, keys.toggleAll, keys.update, keys.preview, keys.pick, keys.notes}
 
 	d.ShortHelpFunc = func() []key.Binding {
 		return help
 	}
 
 	d.FullHelpFunc = func() [][]key.Binding {
-		return [][]key.Binding{help}
+		return [][]key.Binding{help, {keys.scrollDown, keys.scrollUp}}
 	}
 
 	return d
@@ -191,8 +313,8 @@ func (m model) newList() (list.Model, []list.Item) {
 	d := newItemDelegate(keys)
 
 	items := make([]list.Item, 0, len(modules))
-	for _, m := range modules {
-		items = append(items, listModuleItem{Module: m})
+	for _, mod := range modules {
+		items = append(items, listModuleItem{Module: mod, ShowRoot: m.multiRoot})
 	}
 
 	l := list.New(items, d, 0, 0)
@@ -216,10 +338,50 @@ var statusMessageStyle = lipgloss.NewStyle().
 	Render
 
 func (m model) listUpdate(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case versionListMsg:
+		return m.openPicker(msg)
+
+	case previewMsg:
+		return m.openPreview(msg)
+
+	case releaseNotesMsg:
+		m.detail.notes[msg.key] = msg
+		return m, m.syncDetail()
+
+	case apiDiffMsg:
+		return m, tea.Batch(m.setAPIDiff(msg), m.syncDetail())
+
+	case tea.KeyMsg:
+		// the list quits on its own keys, which would skip the report
+		if key.Matches(msg, m.list.KeyMap.ForceQuit) {
+			return m, m.quit()
+		}
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		if key.Matches(msg, m.list.KeyMap.Quit) && !key.Matches(msg, m.list.KeyMap.ClearFilter) {
+			return m, m.quit()
+		}
+		keys := newListKeyMap()
+		switch {
+		case key.Matches(msg, keys.notes):
+			m.detail.open = !m.detail.open
+			m.resizeList()
+			return m, m.syncDetail()
+		case m.detail.open && key.Matches(msg, keys.scrollDown):
+			m.detail.view.HalfPageDown()
+			return m, nil
+		case m.detail.open && key.Matches(msg, keys.scrollUp):
+			m.detail.view.HalfPageUp()
+			return m, nil
+		}
+	}
+
 	newListModel, cmd := m.list.Update(msg)
 	m.list = newListModel
 
 	m.items = m.list.Items()
 
-	return m, cmd
+	return m, tea.Batch(cmd, m.syncDetail())
 }
@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/chaindead/modup/internal/deps"
+)
+
+type versionItem struct {
+	Version  *semver.Version
+	Category string // relative to the current version
+	Latest   bool
+	Target   bool
+}
+
+func (i versionItem) Title() string {
+	title := lipgloss.NewStyle().Bold(true).Render("v" + i.Version.String())
+	if i.Latest {
+		title += " " + badgeStyle.Render("latest")
+	}
+	if i.Target {
+		title += " " + badgeStyle.Render("target")
+	}
+	return title
+}
+
+func (i versionItem) Description() string {
+	catColor := lipgloss.Color("#04B575")
+	if i.Category == "major" {
+		catColor = lipgloss.Color("#F59E0B")
+	}
+	return lipgloss.NewStyle().Foreground(catColor).Render(i.Category)
+}
+
+func (i versionItem) FilterValue() string {
+	return i.Version.String()
+}
+
+type pickKeyMap struct {
+	choose key.Binding
+	back   key.Binding
+}
+
+func newPickKeyMap() *pickKeyMap {
+	return &pickKeyMap{
+		choose: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "to upgrade to version"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc", "q"),
+			key.WithHelp("esc", "to go back"),
+		),
+	}
+}
+
+// newPicker lists versions mod can be upgraded to
+func (m model) newPicker(mod deps.Module, versions []*semver.Version) list.Model {
+	items := make([]list.Item, 0, len(versions))
+	for _, v := range versions {
+		items = append(items, versionItem{
+			Version:  v,
+			Category: mod.WithTarget(v).UpdateCategory,
+			Latest:   v.Equal(mod.Latest),
+			Target:   mod.Target != nil && v.Equal(mod.Target),
+		})
+	}
+
+	keys := newPickKeyMap()
+	d := list.NewDefaultDelegate()
+	d.ShowDescription = true
+	d.Styles.FilterMatch = lipgloss.NewStyle()
+	d.ShortHelpFunc = func() []key.Binding {
+		return []key.Binding{keys.choose, keys.back}
+	}
+	d.FullHelpFunc = func() [][]key.Binding {
+		return [][]key.Binding{{keys.choose, keys.back}}
+	}
+
+	l := list.New(items, d, 0, 0)
+	l.Title = fmt.Sprintf("Upgrade %s from v%s to", mod.UpgradePath(), mod.Current)
+	l.Help = help.New()
+	l.DisableQuitKeybindings()
+	l.SetStatusBarItemName("version", "versions")
+	l.SetFilteringEnabled(true)
+
+	for i, it := range items {
+		if it.(versionItem).Target {
+			l.Select(i)
+			break
+		}
+	}
+
+	h, v := appStyle.GetFrameSize()
+	l.SetSize(m.width-h, m.height-v)
+
+	return l
+}
+
+// openPicker switches to the version picker once versions of a module are listed
+func (m model) openPicker(msg versionListMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, m.list.NewStatusMessage(warnStyle.Render("List versions of " + msg.mod.Path + ": " + msg.err.Error()))
+	}
+	if len(msg.versions) == 0 {
+		return m, m.list.NewStatusMessage(statusMessageStyle("No versions to pick for " + msg.mod.Path))
+	}
+
+	m.picking = msg.mod
+	m.picker = m.newPicker(msg.mod, msg.versions)
+	m.mode = modePick
+
+	return m, nil
+}
+
+func (m model) pickUpdate(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// quit keybindings of the picker are disabled, which disables ForceQuit too
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "ctrl+c" {
+		return m, m.quit()
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok && m.picker.FilterState() != list.Filtering {
+		keys := newPickKeyMap()
+		switch {
+		case key.Matches(msg, keys.back):
+			if m.picker.FilterState() == list.FilterApplied {
+				break
+			}
+			m.mode = modeList
+			return m, nil
+
+		case key.Matches(msg, keys.choose):
+			item, ok := m.picker.SelectedItem().(versionItem)
+			if !ok {
+				return m, nil
+			}
+			m.mode = modeList
+
+			idx := findItemIndex(m.list.Items(), m.picking)
+			if idx < 0 {
+				return m, nil
+			}
+			// a version picked explicitly is no longer held back by the project config
+			mod := m.picking.WithTarget(item.Version)
+			mod.Held, mod.Updatable = "", true
+			var diffCmd tea.Cmd
+			if d, ok := m.detail.apiDiffs[moduleKey(mod)]; ok {
+				mod.Compat = d.diff
+			} else if *apiDiff {
+				diffCmd = m.loadAPIDiff(mod)
+			}
+			setCmd := m.list.SetItem(idx, listModuleItem{Module: mod, Selected: true, ShowRoot: m.multiRoot})
+			m.items = m.list.Items()
+			statusCmd := m.list.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Selected %s v%s", mod.Path, mod.TargetVersion())))
+
+			return m, tea.Batch(setCmd, statusCmd, diffCmd, m.syncDetail())
+		}
+	}
+
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+
+	return m, cmd
+}
@@ -0,0 +1,163 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/chaindead/modup/internal/deps"
+)
+
+var (
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#22C55E"))
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+)
+
+type previewKeyMap struct {
+	back key.Binding
+	down key.Binding
+	up   key.Binding
+}
+
+func newPreviewKeyMap() *previewKeyMap {
+	return &previewKeyMap{
+		back: key.NewBinding(
+			key.WithKeys("esc", "q", "p"),
+			key.WithHelp("esc", "to go back"),
+		),
+		down: key.NewBinding(
+			key.WithKeys("down", "j", "pgdown", "ctrl+d"),
+			key.WithHelp("↓/pgdn", "to scroll"),
+		),
+		up: key.NewBinding(
+			key.WithKeys("up", "k", "pgup", "ctrl+u"),
+			key.WithHelp("↑/pgup", "to scroll back"),
+		),
+	}
+}
+
+// openPreview shows what go get would change for the selected modules
+func (m model) openPreview(msg previewMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, m.list.NewStatusMessage(warnStyle.Render("Preview: " + firstLine(msg.err.Error())))
+	}
+
+	m.preview = viewport.New(0, 0)
+	m.preview.SetContent(previewContent(msg.previews, m.multiRoot))
+	m.resizePreview()
+	m.mode = modePreview
+
+	return m, nil
+}
+
+func (m *model) resizePreview() {
+	h, v := appStyle.GetFrameSize()
+	m.preview.Width = m.width - h
+	// title and help lines
+	m.preview.Height = max(m.height-v-4, 1)
+}
+
+func (m model) previewUpdate(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		keys := newPreviewKeyMap()
+		switch {
+		case key.Matches(msg, keys.back):
+			m.mode = modeList
+			return m, nil
+		case msg.String() == "ctrl+c":
+			return m, m.quit()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.preview, cmd = m.preview.Update(msg)
+
+	return m, cmd
+}
+
+func (m model) viewPreview() string {
+	keys := newPreviewKeyMap()
+	title := lipgloss.NewStyle().Bold(true).Render("Preview of go get, nothing is changed")
+	footer := help.New().ShortHelpView([]key.Binding{keys.down, keys.up, keys.back})
+
+	return appStyle.Render(title + "\n\n" + m.preview.View() + "\n\n" + footer)
+}
+
+// previewContent lists version changes of every main module followed by the diffs
+func previewContent(previews []deps.Preview, showRoot bool) string {
+	var b strings.Builder
+	for i, p := range previews {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if showRoot {
+			b.WriteString(lipgloss.NewStyle().Bold(true).Render(p.Root.Path) + "\n")
+		}
+		b.WriteString(changesTable(p.Changes) + "\n")
+		if p.Diff != "" {
+			b.WriteString("\n" + colorDiff(p.Diff))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// changesTable aligns version changes in columns
+func changesTable(changes []deps.VersionChange) string {
+	if len(changes) == 0 {
+		return dimStyle.Render("No version changes")
+	}
+
+	pathW, fromW, toW := 0, 0, 0
+	for _, c := range changes {
+		pathW = max(pathW, len(c.Path))
+		fromW = max(fromW, len(orDash(c.From)))
+		toW = max(toW, len(orDash(c.To)))
+	}
+
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		kind := c.Kind()
+		switch kind {
+		case "downgrade", "removed":
+			kind = warnStyle.Render(kind)
+		default:
+			kind = dimStyle.Render(kind)
+		}
+		lines = append(lines, fmt.Sprintf("%-*s  %-*s -> %-*s  %s", pathW, c.Path, fromW, orDash(c.From), toW, orDash(c.To), kind))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func colorDiff(diff string) string {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = lipgloss.NewStyle().Bold(true).Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = dimStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = addedStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = removedStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -4,14 +4,19 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/chaindead/modup/internal/deps"
 )
 
 const (
 	modeScan = iota
 	modeList
 	modeUpgrade
+	modePick
+	modePreview
 )
 
 func (m model) View() string {
@@ -22,6 +27,10 @@ func (m model) View() string {
 		return m.viewList()
 	case modeUpgrade:
 		return m.viewUpgrade()
+	case modePick:
+		return appStyle.Render(m.picker.View())
+	case modePreview:
+		return m.viewPreview()
 	default:
 		panic("unreachable")
 	}
@@ -33,10 +42,13 @@ func (m model) viewScan() string {
 
 	pkgCount := fmt.Sprintf(" %*d/%*d", w, m.packages.current, w, n)
 	prog := m.progress.View()
+	if m.packages.cached > 0 {
+		prog = dimStyle.Render(fmt.Sprintf("%d cached ", m.packages.cached)) + prog
+	}
 
 	var lines []string
 	for _, p := range m.scanning {
-		name := currentPkgNameStyle.Render(p.name)
+		name := currentPkgNameStyle.Render(p.label)
 		lines = append(lines, p.spin.View()+" Scanning "+name)
 	}
 
@@ -51,6 +63,9 @@ func (m model) viewScan() string {
 }
 
 func (m model) viewList() string {
+	if m.detail.open {
+		return appStyle.Render(lipgloss.JoinVertical(lipgloss.Left, m.list.View(), m.viewDetail()))
+	}
 	return appStyle.Render(m.list.View())
 }
 func (m model) viewUpgrade() string {
@@ -65,7 +80,14 @@ func (m model) viewUpgrade() string {
 	var info string
 	if n != 0 && m.upgradeIndex < n {
 		pkgName := currentPkgNameStyle.Render(m.upgrading[m.upgradeIndex].Path)
-		info = lipgloss.NewStyle().MaxWidth(cellsAvail).Render("Upgrading " + pkgName)
+		verb := "Upgrading "
+		if *dryRun {
+			verb = "Previewing "
+		}
+		info = lipgloss.NewStyle().MaxWidth(cellsAvail).Render(verb + pkgName)
+	}
+	if len(m.finishing) > 0 {
+		info = lipgloss.NewStyle().MaxWidth(cellsAvail).Render("Running " + currentPkgNameStyle.Render(m.finishStepName(m.finishing[0])))
 	}
 
 	cellsRemaining := max(0, m.width-lipgloss.Width(spin+info+prog+count))
@@ -77,17 +99,108 @@ func (m model) viewUpgrade() string {
 func (m model) printDone() []tea.Cmd {
 	cmds := []tea.Cmd{
 		stepPrint("Done"),
-		textPrint("%s %d succeeded!", checkMark, len(m.upgrading)),
+		textPrint("%s %d succeeded!", checkMark, len(m.upgradedSucceeded)),
 	}
 
+	if m.commits > 0 {
+		cmds = append(cmds, textPrint("%s %d committed, `git revert` undoes a single upgrade", checkMark, m.commits))
+	}
+	if len(m.upgradedBreaking) > 0 {
+		cmds = append(cmds, textPrint("%s %d breaking", failMark, len(m.upgradedBreaking)))
+	}
 	if len(m.upgradedFailed) > 0 {
-		textPrint("%s %d failed", failMark, len(m.upgradedFailed))
-		cmds = append(cmds, stepPrint("Failed"))
+		cmds = append(cmds,
+			textPrint("%s %d failed", failMark, len(m.upgradedFailed)),
+			stepPrint("Failed"),
+		)
 	}
 
 	for _, mod := range m.upgradedFailed {
 		cmds = append(cmds, textPrint("%s %s", failMark, mod.Path))
 	}
 
+	if f := m.finishFailed; f != nil {
+		cmds = append(cmds,
+			stepPrint("Finishing failed"),
+			textPrint("%s %s, later steps skipped, `modup undo` restores go.mod and go.sum", failMark, m.finishStepName(f.step)),
+		)
+	}
+
+	if len(m.upgradedBreaking) > 0 {
+		cmds = append(cmds, stepPrint("Breaking, not upgraded"))
+	}
+	for _, b := range m.upgradedBreaking {
+		cmds = append(cmds, textPrint("%s %s -> v%s\n%s", failMark, b.Module.Path, b.Module.TargetVersion(), indentOutput(b.Output)))
+	}
+
+	if len(m.branches) > 0 {
+		cmds = append(cmds,
+			stepPrint("Branches"),
+			textPrint("%s", branchTable(m.branches)),
+		)
+	}
+
+	if len(m.rewrites) > 0 {
+		cmds = append(cmds, stepPrint("Rewritten imports"))
+	}
+	for _, r := range m.rewrites {
+		cmds = append(cmds, textPrint("%s %s -> %s (%d files)", checkMark, r.mod.Path, r.mod.TargetPath, len(r.files)))
+		for _, f := range r.files {
+			cmds = append(cmds, textPrint("    %s", f))
+		}
+	}
+
+	return append(cmds, m.printDeprecated()...)
+}
+
+func (m model) printDeprecated() []tea.Cmd {
+	if len(m.deprecated) == 0 {
+		return nil
+	}
+
+	cmds := []tea.Cmd{stepPrint("Deprecated")}
+	for _, mod := range m.deprecated {
+		cmds = append(cmds,
+			textPrint("%s %s", failMark, m.requirementName(deps.Requirement{Root: mod.Root, Path: mod.Path})),
+			textPrint("    %s", mod.Deprecated),
+		)
+	}
+
 	return cmds
 }
+
+// importRewrite lists files whose imports were moved to a new major module path
+type importRewrite struct {
+	mod   deps.Module
+	files []string
+}
+
+// upgradeBranch is a branch an upgrade was committed on
+type upgradeBranch struct {
+	mod  deps.Module
+	name string
+}
+
+// branchTable aligns upgraded modules, their versions and branches in columns
+func branchTable(branches []upgradeBranch) string {
+	pathW, fromW, toW := 0, 0, 0
+	for _, b := range branches {
+		pathW = max(pathW, len(b.mod.UpgradePath()))
+		fromW = max(fromW, len(versionOrDash(b.mod.Current)))
+		toW = max(toW, len(versionOrDash(b.mod.TargetVersion())))
+	}
+
+	lines := make([]string, 0, len(branches))
+	for _, b := range branches {
+		lines = append(lines, fmt.Sprintf("%s %-*s  %-*s -> %-*s  %s", checkMark,
+			pathW, b.mod.UpgradePath(), fromW, versionOrDash(b.mod.Current), toW, versionOrDash(b.mod.TargetVersion()), b.name))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func versionOrDash(v *semver.Version) string {
+	if v == nil {
+		return orDash("")
+	}
+	return "v" + v.String()
+}
@@ -23,6 +23,10 @@ func main() {
 		os.Exit(0)
 	}
 
+	if pflag.Arg(0) == "undo" {
+		os.Exit(undo(pflag.Arg(1)))
+	}
+
 	if _, err := tea.NewProgram(tui.NewModel()).Run(); err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chaindead/modup/internal/deps"
+)
+
+// undo restores the files of the session id, the latest one when id is empty
+func undo(id string) int {
+	session, files, err := deps.RestoreSession(".", id)
+	if errors.Is(err, deps.ErrNoSession) {
+		fmt.Println(err)
+		return 1
+	}
+	if err != nil {
+		fmt.Println("undo:", err)
+		var verr *deps.VerifyError
+		if errors.As(err, &verr) {
+			fmt.Println(verr.Output)
+		}
+		if sessions, lerr := deps.Sessions("."); lerr == nil && len(sessions) > 0 {
+			fmt.Println("sessions:")
+			for _, s := range sessions {
+				fmt.Printf("  %s  %s\n", s.ID, s.Created.Format(time.DateTime))
+			}
+		}
+		return 1
+	}
+
+	fmt.Printf("restored session %s from %s\n", session.ID, session.Created.Format(time.DateTime))
+	for _, f := range files {
+		fmt.Println("  " + f)
+	}
+	if len(files) == 0 {
+		fmt.Println("  nothing changed since")
+	}
+	return 0
+}